@@ -0,0 +1,254 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	internalfields "github.com/chanxuehong/log/internal/fields"
+)
+
+// binaryEntryVersion is the version of the binary layout of Entry,
+// the Entry is encoded as the array [version, time, level, traceId, location, message, fields].
+const binaryEntryVersion = 1
+
+const binaryEntryLength = 7
+
+const (
+	binaryMaxDepth  = 64
+	binaryMaxLength = 64 << 20
+)
+
+var (
+	_ErrBinaryMaxDepth  = errors.New("log: the nesting depth of binary value exceeds the limit")
+	_ErrBinaryMaxLength = errors.New("log: the length of binary value exceeds the limit")
+)
+
+// binaryEncoder encodes the values of a binary format, such as CBOR and MessagePack.
+type binaryEncoder interface {
+	appendNil(b *bytes.Buffer)
+	appendBool(b *bytes.Buffer, v bool)
+	appendInt(b *bytes.Buffer, v int64)
+	appendUint(b *bytes.Buffer, v uint64)
+	appendFloat32(b *bytes.Buffer, v float32)
+	appendFloat64(b *bytes.Buffer, v float64)
+	appendString(b *bytes.Buffer, v string)
+	appendBytes(b *bytes.Buffer, v []byte)
+	appendTime(b *bytes.Buffer, v time.Time)
+	appendDuration(b *bytes.Buffer, v time.Duration)
+	appendJSON(b *bytes.Buffer, v json.RawMessage)
+	appendArrayHeader(b *bytes.Buffer, n int)
+	appendMapHeader(b *bytes.Buffer, n int)
+}
+
+// binaryFormat formats the Entry with the binaryEncoder.
+func binaryFormat(enc binaryEncoder, entry *Entry) ([]byte, error) {
+	var buffer *bytes.Buffer
+	if entry.Buffer != nil {
+		buffer = entry.Buffer
+	} else {
+		buffer = bytes.NewBuffer(make([]byte, 0, 16<<10))
+	}
+	enc.appendArrayHeader(buffer, binaryEntryLength)
+	enc.appendUint(buffer, binaryEntryVersion)
+	enc.appendTime(buffer, entry.Time)
+	appendBinaryLevel(enc, buffer, entry.Level)
+	enc.appendString(buffer, entry.TraceId)
+	enc.appendString(buffer, entry.Location)
+	enc.appendString(buffer, entry.Message)
+	fields := entry.Fields
+	if len(fields) > 0 {
+		fixFieldsConflictAndHandleErrorFields(fields, nil)
+	}
+	appendBinaryMap(enc, buffer, fields)
+	return buffer.Bytes(), nil
+}
+
+// appendBinaryLevel writes the built-in level as its number, and the custom level (see RegisterLevel) as its name,
+// since the number of the custom level depends on the order of registration and differs between programs.
+func appendBinaryLevel(enc binaryEncoder, b *bytes.Buffer, level Level) {
+	if level <= TraceLevel {
+		enc.appendUint(b, uint64(level))
+		return
+	}
+	if def, ok := lookupLevel(level); ok {
+		enc.appendString(b, def.Name)
+		return
+	}
+	enc.appendUint(b, uint64(level))
+}
+
+func appendBinaryMap(enc binaryEncoder, b *bytes.Buffer, m map[string]interface{}) {
+	enc.appendMapHeader(b, len(m))
+	for k, v := range m {
+		enc.appendString(b, k)
+		appendBinaryValue(enc, b, v)
+	}
+}
+
+// appendBinaryValue writes value to b with enc, the types which have no binary representation
+// are encoded as JSON, or as the string "!ERROR: <reason>" if they can not be encoded (see appendJSONFieldValue).
+func appendBinaryValue(enc binaryEncoder, b *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		enc.appendNil(b)
+	case bool:
+		enc.appendBool(b, v)
+	case int:
+		enc.appendInt(b, int64(v))
+	case int8:
+		enc.appendInt(b, int64(v))
+	case int16:
+		enc.appendInt(b, int64(v))
+	case int32:
+		enc.appendInt(b, int64(v))
+	case int64:
+		enc.appendInt(b, v)
+	case uint:
+		enc.appendUint(b, uint64(v))
+	case uint8:
+		enc.appendUint(b, uint64(v))
+	case uint16:
+		enc.appendUint(b, uint64(v))
+	case uint32:
+		enc.appendUint(b, uint64(v))
+	case uint64:
+		enc.appendUint(b, v)
+	case uintptr:
+		enc.appendUint(b, uint64(v))
+	case float32:
+		enc.appendFloat32(b, v)
+	case float64:
+		enc.appendFloat64(b, v)
+	case string:
+		enc.appendString(b, v)
+	case json.RawMessage:
+		enc.appendJSON(b, v)
+	case []byte:
+		enc.appendBytes(b, v)
+	case time.Time:
+		enc.appendTime(b, v)
+	case time.Duration:
+		enc.appendDuration(b, v)
+	case error:
+		str, _ := internalfields.MethodText(v)
+		enc.appendString(b, str)
+	case []interface{}:
+		enc.appendArrayHeader(b, len(v))
+		for _, elem := range v {
+			appendBinaryValue(enc, b, elem)
+		}
+	case map[string]interface{}:
+		appendBinaryMap(enc, b, v)
+	default:
+		var buffer bytes.Buffer
+		if err := safeAppendJSONValue(&buffer, value); err != nil {
+			enc.appendString(b, jsonErrorMarker+err.Error())
+			return
+		}
+		enc.appendJSON(b, buffer.Bytes())
+	}
+}
+
+// binaryDecoder decodes the values of a binary format, such as CBOR and MessagePack.
+type binaryDecoder interface {
+	// decodeValue decodes a value, depth is the nesting depth of the value.
+	decodeValue(r *bufio.Reader, depth int) (interface{}, error)
+}
+
+// EntryDecoder reads the entries formatted by CborFormatter or MsgpackFormatter from a stream.
+//
+// The field values are decoded as nil, bool, int64 (uint64 if it overflows int64), float32, float64, string,
+// []byte, time.Time, time.Duration, json.RawMessage, []interface{} and map[string]interface{}.
+type EntryDecoder struct {
+	r   *bufio.Reader
+	dec binaryDecoder
+}
+
+// NewCborDecoder returns an EntryDecoder which reads the entries formatted by CborFormatter from r.
+func NewCborDecoder(r io.Reader) *EntryDecoder {
+	return &EntryDecoder{r: bufio.NewReader(r), dec: cborDecoder{}}
+}
+
+// NewMsgpackDecoder returns an EntryDecoder which reads the entries formatted by MsgpackFormatter from r.
+func NewMsgpackDecoder(r io.Reader) *EntryDecoder {
+	return &EntryDecoder{r: bufio.NewReader(r), dec: msgpackDecoder{}}
+}
+
+// Decode reads the next Entry, it returns io.EOF if there is no more Entry.
+func (d *EntryDecoder) Decode() (*Entry, error) {
+	if _, err := d.r.Peek(1); err != nil {
+		return nil, err
+	}
+	value, err := d.dec.decodeValue(d.r, 0)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	array, ok := value.([]interface{})
+	if !ok || len(array) != binaryEntryLength {
+		return nil, fmt.Errorf("log: invalid binary entry: %v", value)
+	}
+	if version, ok := array[0].(int64); !ok || version != binaryEntryVersion {
+		return nil, fmt.Errorf("log: unsupported binary entry version: %v", array[0])
+	}
+	var entry Entry
+	if entry.Time, ok = array[1].(time.Time); !ok {
+		return nil, fmt.Errorf("log: invalid binary entry time: %v", array[1])
+	}
+	switch level := array[2].(type) {
+	case int64:
+		if level < 0 {
+			return nil, fmt.Errorf("log: invalid binary entry level: %v", level)
+		}
+		entry.Level = Level(level)
+	case string: // the custom level, see appendBinaryLevel
+		if entry.Level, ok = parseLevelString(level); !ok {
+			return nil, fmt.Errorf("log: unknown binary entry level: %q", level)
+		}
+	default:
+		return nil, fmt.Errorf("log: invalid binary entry level: %v", array[2])
+	}
+	if entry.TraceId, ok = array[3].(string); !ok {
+		return nil, fmt.Errorf("log: invalid binary entry trace id: %v", array[3])
+	}
+	if entry.Location, ok = array[4].(string); !ok {
+		return nil, fmt.Errorf("log: invalid binary entry location: %v", array[4])
+	}
+	if entry.Message, ok = array[5].(string); !ok {
+		return nil, fmt.Errorf("log: invalid binary entry message: %v", array[5])
+	}
+	if entry.Fields, ok = array[6].(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("log: invalid binary entry fields: %v", array[6])
+	}
+	if len(entry.Fields) == 0 {
+		entry.Fields = nil
+	}
+	return &entry, nil
+}
+
+// readBinaryBytes reads n bytes from r.
+func readBinaryBytes(r *bufio.Reader, n uint64) ([]byte, error) {
+	if n > binaryMaxLength {
+		return nil, _ErrBinaryMaxLength
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// binaryInt returns n as int64, or as uint64 if it overflows int64.
+func binaryInt(n uint64) interface{} {
+	if n > 1<<63-1 {
+		return n
+	}
+	return int64(n)
+}
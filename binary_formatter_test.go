@@ -0,0 +1,265 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBinaryFormatter_RoundTrip(t *testing.T) {
+	newEntry := func() *Entry {
+		return &Entry{
+			Location: "function(file:line)",
+			Time:     time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+			Level:    WarnLevel,
+			TraceId:  "trace_id_123456789",
+			Message:  "message_123456789",
+			Fields: map[string]interface{}{
+				"nil":      nil,
+				"bool":     true,
+				"int":      -123456789,
+				"int8":     int8(-8),
+				"uint":     uint(300),
+				"uint64":   uint64(math.MaxUint64),
+				"float32":  float32(3.5),
+				"float64":  math.Pi,
+				"string":   "中文 string",
+				"long":     string(bytes.Repeat([]byte("x"), 70000)),
+				"bytes":    []byte{0, 1, 2},
+				"time":     time.Date(2018, time.May, 20, 16, 20, 30, 1, time.FixedZone("", 8*60*60)),
+				"duration": -1500 * time.Millisecond,
+				"raw":      json.RawMessage(`{"a":1}`),
+				"struct":   struct{ A int }{1},
+				"error":    testContextError2{}, // error with ErrorContextJSON
+				"array":    []interface{}{"a", 1, []interface{}{}},
+				"map":      map[string]interface{}{"b": false},
+			},
+		}
+	}
+	want := map[string]interface{}{
+		"nil":           nil,
+		"bool":          true,
+		"int":           int64(-123456789),
+		"int8":          int64(-8),
+		"uint":          int64(300),
+		"uint64":        uint64(math.MaxUint64),
+		"float32":       float32(3.5),
+		"float64":       math.Pi,
+		"string":        "中文 string",
+		"long":          string(bytes.Repeat([]byte("x"), 70000)),
+		"bytes":         []byte{0, 1, 2},
+		"duration":      -1500 * time.Millisecond,
+		"raw":           json.RawMessage(`{"a":1}`),
+		"struct":        json.RawMessage(`{"A":1}`),
+		"error":         "context_error2_error_123456789",
+		"error_context": json.RawMessage(`{"key":"context_error2_context_json_123456789"}`),
+		"array":         []interface{}{"a", int64(1), []interface{}{}},
+		"map":           map[string]interface{}{"b": false},
+	}
+	wantTime := time.Date(2018, time.May, 20, 8, 20, 30, 1, time.UTC)
+
+	for _, v := range []struct {
+		name      string
+		formatter Formatter
+		decoder   func(io.Reader) *EntryDecoder
+	}{
+		{"cbor", CborFormatter, NewCborDecoder},
+		{"msgpack", MsgpackFormatter, NewMsgpackDecoder},
+	} {
+		var stream bytes.Buffer
+		for i := 0; i < 2; i++ {
+			data, err := v.formatter.Format(newEntry())
+			if err != nil {
+				t.Error(err.Error())
+				return
+			}
+			stream.Write(data)
+		}
+		// an entry without fields
+		data, err := v.formatter.Format(&Entry{Time: time.Unix(0, 0), Level: DebugLevel})
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		stream.Write(data)
+
+		decoder := v.decoder(&stream)
+		for i := 0; i < 2; i++ {
+			entry, err := decoder.Decode()
+			if err != nil {
+				t.Errorf("%s: %s", v.name, err.Error())
+				return
+			}
+			wantEntry := newEntry()
+			if !entry.Time.Equal(wantEntry.Time) || entry.Level != wantEntry.Level || entry.TraceId != wantEntry.TraceId ||
+				entry.Location != wantEntry.Location || entry.Message != wantEntry.Message {
+				t.Errorf("%s:\nhave:%+v\nwant:%+v", v.name, entry, wantEntry)
+				return
+			}
+			fieldTime, ok := entry.Fields["time"].(time.Time)
+			if !ok || !fieldTime.Equal(wantTime) {
+				t.Errorf("%s: have:%v, want:%v", v.name, entry.Fields["time"], wantTime)
+				return
+			}
+			delete(entry.Fields, "time")
+			if !reflect.DeepEqual(entry.Fields, want) {
+				t.Errorf("%s:\nhave:%#v\nwant:%#v", v.name, entry.Fields, want)
+				return
+			}
+		}
+		entry, err := decoder.Decode()
+		if err != nil {
+			t.Errorf("%s: %s", v.name, err.Error())
+			return
+		}
+		if entry.Level != DebugLevel || entry.Time.UnixNano() != 0 || entry.Fields != nil {
+			t.Errorf("%s: unexpected entry %+v", v.name, entry)
+			return
+		}
+		if _, err = decoder.Decode(); err != io.EOF {
+			t.Errorf("%s: have:%v, want:%v", v.name, err, io.EOF)
+			return
+		}
+	}
+}
+
+func TestBinaryEncoder(t *testing.T) {
+	tests := []struct {
+		value   interface{}
+		cbor    []byte
+		msgpack []byte
+	}{
+		{nil, []byte{0xf6}, []byte{0xc0}},
+		{false, []byte{0xf4}, []byte{0xc2}},
+		{0, []byte{0x00}, []byte{0x00}},
+		{23, []byte{0x17}, []byte{0x17}},
+		{24, []byte{0x18, 0x18}, []byte{0x18}},
+		{500, []byte{0x19, 0x01, 0xf4}, []byte{0xcd, 0x01, 0xf4}},
+		{-1, []byte{0x20}, []byte{0xff}},
+		{-33, []byte{0x38, 0x20}, []byte{0xd0, 0xdf}},
+		{-1000, []byte{0x39, 0x03, 0xe7}, []byte{0xd1, 0xfc, 0x18}},
+		{1.5, []byte{0xfb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"a", []byte{0x61, 'a'}, []byte{0xa1, 'a'}},
+		{[]byte{1}, []byte{0x41, 1}, []byte{0xc4, 1, 1}},
+		{[]interface{}{1}, []byte{0x81, 1}, []byte{0x91, 1}},
+		{map[string]interface{}{"a": 1}, []byte{0xa1, 0x61, 'a', 1}, []byte{0x81, 0xa1, 'a', 1}},
+	}
+	for _, v := range tests {
+		var buffer bytes.Buffer
+		appendBinaryValue(cborEncoder{}, &buffer, v.value)
+		if !bytes.Equal(buffer.Bytes(), v.cbor) {
+			t.Errorf("cbor: value:%v, have:%x, want:%x", v.value, buffer.Bytes(), v.cbor)
+			return
+		}
+		buffer.Reset()
+		appendBinaryValue(msgpackEncoder{}, &buffer, v.value)
+		if !bytes.Equal(buffer.Bytes(), v.msgpack) {
+			t.Errorf("msgpack: value:%v, have:%x, want:%x", v.value, buffer.Bytes(), v.msgpack)
+			return
+		}
+	}
+}
+
+func TestEntryDecoder_Invalid(t *testing.T) {
+	tests := []struct {
+		decoder *EntryDecoder
+	}{
+		{NewCborDecoder(bytes.NewReader([]byte{0x87, 0x01}))},                      // truncated
+		{NewCborDecoder(bytes.NewReader([]byte{0x9f}))},                            // indefinite-length array
+		{NewCborDecoder(bytes.NewReader([]byte{0x82, 0x01, 0x02}))},                // not an entry
+		{NewMsgpackDecoder(bytes.NewReader([]byte{0x97, 0x01}))},                   // truncated
+		{NewMsgpackDecoder(bytes.NewReader([]byte{0xc1}))},                         // never used
+		{NewMsgpackDecoder(bytes.NewReader([]byte{0xdd, 0xff, 0xff, 0xff, 0xff}))}, // too long
+	}
+	for i, v := range tests {
+		if _, err := v.decoder.Decode(); err == nil || err == io.EOF {
+			t.Errorf("%d: want error, have:%v", i, err)
+			return
+		}
+	}
+}
+
+func TestBinaryFormatter_CustomLevel(t *testing.T) {
+	defer resetLevelRegistry()
+
+	newDecoders := map[string]func(r io.Reader) *EntryDecoder{
+		"cbor":    NewCborDecoder,
+		"msgpack": NewMsgpackDecoder,
+	}
+	for name, formatter := range map[string]Formatter{"cbor": CborFormatter, "msgpack": MsgpackFormatter} {
+		// the writer registers notice first
+		resetLevelRegistry()
+		notice := MustRegisterLevel(LevelDefinition{Name: "notice", Rank: 350})
+		data, err := formatter.Format(&Entry{Level: notice, Time: time.Unix(0, 0).UTC()})
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+
+		// the reader registers the levels in a different order
+		resetLevelRegistry()
+		MustRegisterLevel(LevelDefinition{Name: "critical", Rank: 150})
+		notice = MustRegisterLevel(LevelDefinition{Name: "notice", Rank: 350})
+		entry, err := newDecoders[name](bytes.NewReader(data)).Decode()
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if entry.Level != notice {
+			t.Errorf("format:%s, have:%s, want:%s", name, entry.Level, notice)
+			return
+		}
+
+		// the reader does not register the level
+		resetLevelRegistry()
+		if _, err = newDecoders[name](bytes.NewReader(data)).Decode(); err == nil {
+			t.Errorf("format:%s, want error", name)
+			return
+		}
+	}
+}
+
+func TestBinaryFormatter_FormatBadValue(t *testing.T) {
+	newDecoders := map[string]func(r io.Reader) *EntryDecoder{
+		"cbor":    NewCborDecoder,
+		"msgpack": NewMsgpackDecoder,
+	}
+	for name, formatter := range map[string]Formatter{"cbor": CborFormatter, "msgpack": MsgpackFormatter} {
+		entry := &Entry{
+			Time:    time.Date(2018, time.May, 20, 8, 20, 30, 666000000, time.UTC),
+			Level:   InfoLevel,
+			Message: "msg",
+			Fields: map[string]interface{}{
+				"a": testPanicMarshaler{},
+				"b": []interface{}{testPanicMarshaler{}},
+				"c": map[string]interface{}{"d": testPanicMarshaler{}},
+				"e": "ok",
+			},
+		}
+		data, err := formatter.Format(entry)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		decoded, err := newDecoders[name](bytes.NewReader(data)).Decode()
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		marker := "!ERROR: panic: marshal_panic_123456789"
+		want := map[string]interface{}{
+			"a": marker,
+			"b": []interface{}{marker},
+			"c": map[string]interface{}{"d": marker},
+			"e": "ok",
+		}
+		if !reflect.DeepEqual(decoded.Fields, want) {
+			t.Errorf("format:%s\nhave:%v\nwant:%v", name, decoded.Fields, want)
+			return
+		}
+	}
+}
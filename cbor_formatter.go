@@ -0,0 +1,295 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	cborMajorUint   = 0 << 5
+	cborMajorNegInt = 1 << 5
+	cborMajorBytes  = 2 << 5
+	cborMajorString = 3 << 5
+	cborMajorArray  = 4 << 5
+	cborMajorMap    = 5 << 5
+	cborMajorTag    = 6 << 5
+	cborMajorSimple = 7 << 5
+
+	cborFalse   = cborMajorSimple | 20
+	cborTrue    = cborMajorSimple | 21
+	cborNull    = cborMajorSimple | 22
+	cborFloat32 = cborMajorSimple | 26
+	cborFloat64 = cborMajorSimple | 27
+
+	cborTagDateTime     = 0   // RFC 3339 date/time string
+	cborTagEmbeddedJSON = 262 // byte string containing JSON
+
+	// cborTagDuration is the private tag of time.Duration, the content is the nanoseconds as an integer,
+	// it is "logd" in ASCII.
+	cborTagDuration = 0x6c6f6764
+)
+
+var _ErrCborUnsupported = errors.New("log: unsupported CBOR data item")
+
+// CborFormatter formats the Entry as a CBOR (RFC 8949) data item, see NewCborDecoder to read it back.
+//
+// The Entry is encoded as the array [version, time, level, traceId, location, message, fields], the level is
+// the number of the built-in level or the name of the custom level (see RegisterLevel), the times are
+// encoded as the tag 0 (RFC 3339 string with nanoseconds), the json.RawMessage as the tag 262 (embedded JSON),
+// and the time.Duration as the private tag 0x6c6f6764 ("logd") with the nanoseconds.
+// The output is not terminated by a newline since CBOR data items are self-delimiting.
+var CborFormatter Formatter = cborFormatter{}
+
+type cborFormatter struct{}
+
+func (cborFormatter) Format(entry *Entry) ([]byte, error) {
+	return binaryFormat(cborEncoder{}, entry)
+}
+
+type cborEncoder struct{}
+
+func (cborEncoder) appendHead(b *bytes.Buffer, major byte, n uint64) {
+	switch {
+	case n < 24:
+		b.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		b.WriteByte(major | 24)
+		b.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		var scratch [2]byte
+		binary.BigEndian.PutUint16(scratch[:], uint16(n))
+		b.WriteByte(major | 25)
+		b.Write(scratch[:])
+	case n <= math.MaxUint32:
+		var scratch [4]byte
+		binary.BigEndian.PutUint32(scratch[:], uint32(n))
+		b.WriteByte(major | 26)
+		b.Write(scratch[:])
+	default:
+		var scratch [8]byte
+		binary.BigEndian.PutUint64(scratch[:], n)
+		b.WriteByte(major | 27)
+		b.Write(scratch[:])
+	}
+}
+
+func (cborEncoder) appendNil(b *bytes.Buffer) {
+	b.WriteByte(cborNull)
+}
+
+func (cborEncoder) appendBool(b *bytes.Buffer, v bool) {
+	if v {
+		b.WriteByte(cborTrue)
+	} else {
+		b.WriteByte(cborFalse)
+	}
+}
+
+func (e cborEncoder) appendInt(b *bytes.Buffer, v int64) {
+	if v < 0 {
+		e.appendHead(b, cborMajorNegInt, uint64(^v))
+		return
+	}
+	e.appendHead(b, cborMajorUint, uint64(v))
+}
+
+func (e cborEncoder) appendUint(b *bytes.Buffer, v uint64) {
+	e.appendHead(b, cborMajorUint, v)
+}
+
+func (cborEncoder) appendFloat32(b *bytes.Buffer, v float32) {
+	var scratch [4]byte
+	binary.BigEndian.PutUint32(scratch[:], math.Float32bits(v))
+	b.WriteByte(cborFloat32)
+	b.Write(scratch[:])
+}
+
+func (cborEncoder) appendFloat64(b *bytes.Buffer, v float64) {
+	var scratch [8]byte
+	binary.BigEndian.PutUint64(scratch[:], math.Float64bits(v))
+	b.WriteByte(cborFloat64)
+	b.Write(scratch[:])
+}
+
+func (e cborEncoder) appendString(b *bytes.Buffer, v string) {
+	e.appendHead(b, cborMajorString, uint64(len(v)))
+	b.WriteString(v)
+}
+
+func (e cborEncoder) appendBytes(b *bytes.Buffer, v []byte) {
+	e.appendHead(b, cborMajorBytes, uint64(len(v)))
+	b.Write(v)
+}
+
+func (e cborEncoder) appendTime(b *bytes.Buffer, v time.Time) {
+	var scratch [64]byte
+	data := v.AppendFormat(scratch[:0], time.RFC3339Nano)
+	e.appendHead(b, cborMajorTag, cborTagDateTime)
+	e.appendHead(b, cborMajorString, uint64(len(data)))
+	b.Write(data)
+}
+
+func (e cborEncoder) appendDuration(b *bytes.Buffer, v time.Duration) {
+	e.appendHead(b, cborMajorTag, cborTagDuration)
+	e.appendInt(b, int64(v))
+}
+
+func (e cborEncoder) appendJSON(b *bytes.Buffer, v json.RawMessage) {
+	e.appendHead(b, cborMajorTag, cborTagEmbeddedJSON)
+	e.appendBytes(b, v)
+}
+
+func (e cborEncoder) appendArrayHeader(b *bytes.Buffer, n int) {
+	e.appendHead(b, cborMajorArray, uint64(n))
+}
+
+func (e cborEncoder) appendMapHeader(b *bytes.Buffer, n int) {
+	e.appendHead(b, cborMajorMap, uint64(n))
+}
+
+type cborDecoder struct{}
+
+// readHead reads the initial byte and the argument of a data item,
+// the indefinite length (additional information 31) is not supported.
+func (cborDecoder) readHead(r *bufio.Reader) (major byte, info byte, n uint64, err error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	major, info = c&0xe0, c&0x1f
+	var size int
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, 0, _ErrCborUnsupported
+	}
+	var scratch [8]byte
+	if _, err = io.ReadFull(r, scratch[8-size:]); err != nil {
+		return 0, 0, 0, err
+	}
+	return major, info, binary.BigEndian.Uint64(scratch[:]), nil
+}
+
+func (d cborDecoder) decodeValue(r *bufio.Reader, depth int) (interface{}, error) {
+	if depth > binaryMaxDepth {
+		return nil, _ErrBinaryMaxDepth
+	}
+	major, info, n, err := d.readHead(r)
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case cborMajorUint:
+		return binaryInt(n), nil
+	case cborMajorNegInt:
+		if n > math.MaxInt64 {
+			return nil, fmt.Errorf("log: CBOR negative integer overflows int64")
+		}
+		return ^int64(n), nil
+	case cborMajorBytes:
+		return readBinaryBytes(r, n)
+	case cborMajorString:
+		data, err := readBinaryBytes(r, n)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	case cborMajorArray:
+		if n > binaryMaxLength {
+			return nil, _ErrBinaryMaxLength
+		}
+		array := make([]interface{}, 0, minInt(int(n), 1024))
+		for i := uint64(0); i < n; i++ {
+			elem, err := d.decodeValue(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, elem)
+		}
+		return array, nil
+	case cborMajorMap:
+		if n > binaryMaxLength {
+			return nil, _ErrBinaryMaxLength
+		}
+		m := make(map[string]interface{}, minInt(int(n), 1024))
+		for i := uint64(0); i < n; i++ {
+			key, err := d.decodeValue(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			k, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("log: CBOR map key must be string, have %T", key)
+			}
+			if m[k], err = d.decodeValue(r, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case cborMajorTag:
+		content, err := d.decodeValue(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		switch n {
+		case cborTagDateTime:
+			str, ok := content.(string)
+			if !ok {
+				return nil, fmt.Errorf("log: CBOR date/time must be string, have %T", content)
+			}
+			return time.Parse(time.RFC3339Nano, str)
+		case cborTagDuration:
+			nsec, ok := content.(int64)
+			if !ok {
+				return nil, fmt.Errorf("log: CBOR duration must be int64, have %T", content)
+			}
+			return time.Duration(nsec), nil
+		case cborTagEmbeddedJSON:
+			data, ok := content.([]byte)
+			if !ok {
+				return nil, fmt.Errorf("log: CBOR embedded JSON must be bytes, have %T", content)
+			}
+			return json.RawMessage(data), nil
+		default:
+			return content, nil
+		}
+	default: // cborMajorSimple
+		switch major | info {
+		case cborFalse:
+			return false, nil
+		case cborTrue:
+			return true, nil
+		case cborNull:
+			return nil, nil
+		case cborFloat32:
+			return math.Float32frombits(uint32(n)), nil
+		case cborFloat64:
+			return math.Float64frombits(n), nil
+		default:
+			return nil, _ErrCborUnsupported
+		}
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -31,3 +31,10 @@ func (w *concurrentWriter) Write(p []byte) (n int, err error) {
 	defer w.mu.Unlock()
 	return w.w.Write(p)
 }
+
+// Flush flushes the underlying io.Writer if it has the Flush() error or Sync() error method, see WithFatalExit.
+func (w *concurrentWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return flushWriter(w.w)
+}
@@ -0,0 +1,223 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	consoleLevelWidth    = len(WarnLevelString)
+	consoleLocationWidth = 40
+	consoleIndent        = "    "
+)
+
+const (
+	ansiReset   = "\x1b[0m"
+	ansiFaint   = "\x1b[2m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiBlue    = "\x1b[34m"
+	ansiMagenta = "\x1b[35m"
+	ansiCyan    = "\x1b[36m"
+)
+
+// ConsoleFormatter formats the Entry as a colored, column-aligned line for reading in a terminal.
+//
+// The level and location are padded to fixed widths, the multi-line values (for example the
+// pretty-printed *_context fields and stack traces) are written indented below the line.
+// The color is enabled only if the output of the Logger is a terminal and the NO_COLOR environment variable
+// is not set, see WithColor to override it.
+var ConsoleFormatter Formatter = NewConsoleFormatter()
+
+// NewConsoleFormatter returns a Formatter which formats the Entry as a colored, column-aligned line.
+func NewConsoleFormatter(opts ...FormatterOption) Formatter {
+	o := newFormatterOptions(opts)
+	return consoleFormatter{
+		opts:  o,
+		color: o.useColor(),
+		auto:  o.autoColor(),
+	}
+}
+
+type consoleFormatter struct {
+	opts  *formatterOptions
+	color bool
+	auto  bool // the color is decided by the output, see colorFormatter
+}
+
+func (f consoleFormatter) formatColor(entry *Entry, color bool) ([]byte, error) {
+	if f.auto {
+		f.color = color
+	}
+	return f.Format(entry)
+}
+
+func (f consoleFormatter) Format(entry *Entry) ([]byte, error) {
+	var buffer *bytes.Buffer
+	if entry.Buffer != nil {
+		buffer = entry.Buffer
+	} else {
+		buffer = bytes.NewBuffer(make([]byte, 0, 16<<10))
+	}
+	opts := f.opts
+	if opts.hasTime() {
+		f.appendColored(buffer, ansiFaint, opts.formatTime(entry.Time))
+		buffer.WriteByte(' ')
+	}
+	if opts.hasLevel() {
+		f.appendColored(buffer, levelColor(entry.Level), padRight(strings.ToUpper(entry.Level.String()), consoleLevelWidth))
+		buffer.WriteByte(' ')
+	}
+	if opts.hasLocation() {
+		f.appendColored(buffer, ansiFaint, padRight(entry.Location, consoleLocationWidth))
+		buffer.WriteByte(' ')
+	}
+	if opts.hasMessage() {
+		buffer.WriteString(entry.Message)
+	}
+	if entry.TraceId != "" && opts.hasTraceId(entry.TraceId) {
+		f.appendKeyValue(buffer, opts.keys.TraceId, entry.TraceId)
+	}
+
+	var (
+		multilineKeys   []string
+		multilineValues []string
+	)
+	if fields := entry.Fields; len(fields) > 0 {
+		fixFieldsConflictAndHandleErrorFields(fields, opts.stdKeys)
+
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v := f.value(k, fields[k])
+			if strings.IndexByte(v, '\n') >= 0 {
+				multilineKeys = append(multilineKeys, k)
+				multilineValues = append(multilineValues, v)
+				continue
+			}
+			f.appendKeyValue(buffer, k, v)
+		}
+	}
+	for i, k := range multilineKeys {
+		buffer.WriteByte('\n')
+		buffer.WriteString(consoleIndent)
+		f.appendColored(buffer, ansiCyan, k)
+		buffer.WriteByte(':')
+		for _, line := range strings.Split(multilineValues[i], "\n") {
+			buffer.WriteByte('\n')
+			buffer.WriteString(consoleIndent)
+			buffer.WriteString(consoleIndent)
+			buffer.WriteString(line)
+		}
+	}
+	buffer.WriteByte('\n')
+	return buffer.Bytes(), nil
+}
+
+func (f consoleFormatter) appendKeyValue(b *bytes.Buffer, key, value string) {
+	b.WriteString("  ")
+	f.appendColored(b, ansiCyan, key)
+	b.WriteByte('=')
+	b.WriteString(value)
+}
+
+func (f consoleFormatter) appendColored(b *bytes.Buffer, color, str string) {
+	if !f.color {
+		b.WriteString(str)
+		return
+	}
+	b.WriteString(color)
+	b.WriteString(str)
+	b.WriteString(ansiReset)
+}
+
+// value returns the string form of the field value,
+// the JSON object of the *_context field is pretty-printed.
+func (f consoleFormatter) value(key string, value interface{}) string {
+	if raw, ok := value.(json.RawMessage); ok && strings.HasSuffix(key, "_context") {
+		var buffer bytes.Buffer
+		if err := json.Indent(&buffer, raw, "", "  "); err == nil {
+			return buffer.String()
+		}
+	}
+	return strings.TrimRight(f.opts.textValue(value), "\n")
+}
+
+// levelColor returns the color of the level, the custom level has the color of the nearest built-in level.
+func levelColor(level Level) string {
+	switch level.builtin() {
+	case FatalLevel:
+		return ansiMagenta
+	case ErrorLevel:
+		return ansiRed
+	case WarnLevel:
+		return ansiYellow
+	case InfoLevel:
+		return ansiGreen
+	case DebugLevel:
+		return ansiBlue
+	default:
+		return ansiFaint
+	}
+}
+
+func padRight(str string, width int) string {
+	n := utf8.RuneCountInString(str)
+	if n >= width {
+		return str
+	}
+	return str + strings.Repeat(" ", width-n)
+}
+
+// colorFormatter is implemented by the formatters which write the color automatically (see WithColor),
+// the Logger calls formatColor instead of Format with whether its output is a color terminal,
+// while Format writes the color only if it is enabled explicitly.
+type colorFormatter interface {
+	formatColor(entry *Entry, color bool) ([]byte, error)
+}
+
+// formatEntry formats entry by formatter, color reports whether the output is a color terminal.
+func formatEntry(formatter Formatter, entry *Entry, color bool) ([]byte, error) {
+	if f, ok := formatter.(colorFormatter); ok {
+		return f.formatColor(entry, color)
+	}
+	return formatter.Format(entry)
+}
+
+// isColorOutput reports whether the color should be written to w, w is a color terminal (see isColorTerminal)
+// or the ConcurrentWriter of it.
+func isColorOutput(w io.Writer) bool {
+	if cw, ok := w.(*concurrentWriter); ok {
+		w = cw.w
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	return isColorTerminal(f)
+}
+
+// isColorTerminal reports whether the color should be written to f,
+// that is f is a terminal, the NO_COLOR environment variable is not set and TERM is not "dumb".
+func isColorTerminal(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	if f == nil {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
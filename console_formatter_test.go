@@ -0,0 +1,157 @@
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConsoleFormatter_Format(t *testing.T) {
+	newEntry := func() *Entry {
+		return &Entry{
+			Location: "function(file:line)",
+			Time:     time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+			Level:    InfoLevel,
+			TraceId:  "trace_id_123456789",
+			Message:  "message_123456789",
+			Fields: map[string]interface{}{
+				"key1": "fields_value1",
+				"key2": testContextError2{}, // error with ErrorContextJSON
+				"key3": "line1\nline2\n",
+			},
+			Buffer: nil,
+		}
+	}
+
+	// without color
+	{
+		have, err := NewConsoleFormatter(WithColor(false)).Format(newEntry())
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		want := "2018-05-20 16:20:30.666 INFO    function(file:line)                      message_123456789" +
+			"  request_id=trace_id_123456789  key1=fields_value1  key2=context_error2_error_123456789\n" +
+			"    key2_context:\n" +
+			"        {\n" +
+			"          \"key\": \"context_error2_context_json_123456789\"\n" +
+			"        }\n" +
+			"    key3:\n" +
+			"        line1\n" +
+			"        line2\n"
+		if string(have) != want {
+			t.Errorf("\nhave:%s\nwant:%s", have, want)
+			return
+		}
+	}
+	// with color
+	{
+		entry := newEntry()
+		entry.Fields = nil
+		have, err := NewConsoleFormatter(WithColor(true)).Format(entry)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		want := "\x1b[2m2018-05-20 16:20:30.666\x1b[0m \x1b[32mINFO   \x1b[0m " +
+			"\x1b[2mfunction(file:line)                     \x1b[0m message_123456789" +
+			"  \x1b[36mrequest_id\x1b[0m=trace_id_123456789\n"
+		if string(have) != want {
+			t.Errorf("\nhave:%q\nwant:%q", have, want)
+			return
+		}
+	}
+}
+
+func TestIsColorTerminal(t *testing.T) {
+	f, err := ioutil.TempFile("", "log-console-*")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if isColorTerminal(f) {
+		t.Error("want false for regular file")
+		return
+	}
+	if isColorTerminal(nil) {
+		t.Error("want false for nil file")
+		return
+	}
+}
+
+func TestFormatEntry_Color(t *testing.T) {
+	entry := &Entry{
+		Level:   InfoLevel,
+		Message: "message_123456789",
+	}
+	opts := []FormatterOption{WithFieldKeys(FieldKeys{Time: OmitFieldKey, Location: OmitFieldKey})}
+	colored := "\x1b[32mINFO   \x1b[0m message_123456789\n"
+	plain := "INFO    message_123456789\n"
+
+	tests := []struct {
+		formatter Formatter
+		color     bool // the output is a color terminal
+		want      string
+	}{
+		{NewConsoleFormatter(opts...), true, colored},
+		{NewConsoleFormatter(opts...), false, plain},
+		{NewConsoleFormatter(append(opts, WithColor(false))...), true, plain},
+		{NewConsoleFormatter(append(opts, WithColor(true))...), false, colored},
+		{MustNewTemplateFormatter(`{{levelColor .Level (upper .Level | pad 7)}} {{.Message}}`), true, colored},
+		{MustNewTemplateFormatter(`{{levelColor .Level (upper .Level | pad 7)}} {{.Message}}`), false, plain},
+		{MustNewTemplateFormatter(`{{levelColor .Level (upper .Level | pad 7)}} {{.Message}}`, WithColor(false)), true, plain},
+	}
+	for i, v := range tests {
+		have, err := formatEntry(v.formatter, entry, v.color)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if string(have) != v.want {
+			t.Errorf("index:%d, have:%q, want:%q", i, have, v.want)
+			return
+		}
+	}
+
+	// Format does not know the output, the automatic color is disabled
+	have, err := NewConsoleFormatter(opts...).Format(entry)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if string(have) != plain {
+		t.Errorf("have:%q, want:%q", have, plain)
+		return
+	}
+}
+
+func TestLogger_ColorOutput(t *testing.T) {
+	f, err := ioutil.TempFile("", "log-console-*")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	lg := New(WithFormatter(NewConsoleFormatter()), WithOutput(ConcurrentWriter(f)))
+	lg.Info("message_123456789")
+	data, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if bytes.Contains(data, []byte("\x1b[")) {
+		t.Errorf("want no color for regular file, have:%q", data)
+		return
+	}
+	if isColorOutput(f) || isColorOutput(ConcurrentWriter(f)) || isColorOutput(&bytes.Buffer{}) {
+		t.Error("want false for non-terminal output")
+		return
+	}
+}
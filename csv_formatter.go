@@ -0,0 +1,221 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+// The standard columns of NewCsvFormatter, the other columns are the keys of Entry.Fields.
+const (
+	CsvColumnTime     = "$time"
+	CsvColumnLevel    = "$level"
+	CsvColumnTraceId  = "$request_id"
+	CsvColumnLocation = "$location"
+	CsvColumnMessage  = "$msg"
+)
+
+// CsvFormatter formats the Entry as a CSV row with the standard columns time, level, request_id, location and msg
+// and the trailing column of the fields, see NewCsvFormatter.
+var CsvFormatter Formatter = NewCsvFormatter(csvStdColumns)
+
+// TsvFormatter formats the Entry as a TSV row with the same columns as CsvFormatter, see NewTsvFormatter.
+var TsvFormatter Formatter = NewTsvFormatter(csvStdColumns)
+
+var csvStdColumns = []string{CsvColumnTime, CsvColumnLevel, CsvColumnTraceId, CsvColumnLocation, CsvColumnMessage}
+
+// HeaderFormatter is implemented by the formatters which write a header before the formatted entries,
+// for example the Formatter returned by NewCsvFormatter.
+type HeaderFormatter interface {
+	Formatter
+
+	// Header returns the header.
+	Header() []byte
+
+	// WriteHeader writes the header to w if w is new, that is w is not a file or it is an empty file.
+	// It is normally called once after the output file is opened.
+	WriteHeader(w io.Writer) error
+}
+
+// NewCsvFormatter returns a Formatter which formats the Entry as a CSV row with the declared columns,
+// columns are the standard columns (CsvColumnTime etc.) or the keys of Entry.Fields.
+//
+// The values are quoted as RFC 4180 requires, that is the value which contains the delimiter, '"', '\r' or '\n'
+// (or begins with a space) is enclosed in double quotes, and the double quotes in it are doubled.
+// The missing fields are written as empty cells, and the undeclared fields are written as a JSON object
+// in the trailing column (see WithFieldsNamespace), the cell is empty if there is no undeclared field.
+// The returned Formatter implements HeaderFormatter.
+func NewCsvFormatter(columns []string, opts ...FormatterOption) Formatter {
+	return newCsvFormatter(',', columns, opts)
+}
+
+// NewTsvFormatter returns a Formatter which writes tab-separated values, see NewCsvFormatter.
+func NewTsvFormatter(columns []string, opts ...FormatterOption) Formatter {
+	return newCsvFormatter('\t', columns, opts)
+}
+
+type csvFormatter struct {
+	opts      *formatterOptions
+	delimiter byte
+	columns   []string
+	declared  map[string]bool // the declared fields
+}
+
+var _ HeaderFormatter = (*csvFormatter)(nil)
+
+func newCsvFormatter(delimiter byte, columns []string, opts []FormatterOption) *csvFormatter {
+	f := &csvFormatter{
+		opts:      newFormatterOptions(opts),
+		delimiter: delimiter,
+		columns:   make([]string, len(columns)),
+		declared:  make(map[string]bool, len(columns)),
+	}
+	copy(f.columns, columns)
+	for _, column := range columns {
+		if !isCsvStdColumn(column) {
+			f.declared[column] = true
+		}
+	}
+	return f
+}
+
+func isCsvStdColumn(column string) bool {
+	switch column {
+	case CsvColumnTime, CsvColumnLevel, CsvColumnTraceId, CsvColumnLocation, CsvColumnMessage:
+		return true
+	default:
+		return false
+	}
+}
+
+// hasExtraColumn reports whether the trailing column of the undeclared fields is written.
+func (f *csvFormatter) hasExtraColumn() bool {
+	return f.opts.fieldsNamespace != OmitFieldKey
+}
+
+// Header returns the header row, the standard columns are named without the '$' prefix.
+func (f *csvFormatter) Header() []byte {
+	var buffer bytes.Buffer
+	for i, column := range f.columns {
+		if i > 0 {
+			buffer.WriteByte(f.delimiter)
+		}
+		f.appendCell(&buffer, strings.TrimPrefix(column, "$"))
+	}
+	if f.hasExtraColumn() {
+		if len(f.columns) > 0 {
+			buffer.WriteByte(f.delimiter)
+		}
+		f.appendCell(&buffer, f.opts.fieldsNamespace)
+	}
+	buffer.WriteByte('\n')
+	return buffer.Bytes()
+}
+
+// WriteHeader writes the header row to w if w is new, see HeaderFormatter.
+func (f *csvFormatter) WriteHeader(w io.Writer) error {
+	if file, ok := w.(*os.File); ok {
+		fi, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		if fi.Mode().IsRegular() && fi.Size() > 0 {
+			return nil
+		}
+	}
+	_, err := w.Write(f.Header())
+	return err
+}
+
+func (f *csvFormatter) Format(entry *Entry) ([]byte, error) {
+	var buffer *bytes.Buffer
+	if entry.Buffer != nil {
+		buffer = entry.Buffer
+	} else {
+		buffer = bytes.NewBuffer(make([]byte, 0, 16<<10))
+	}
+	fields := entry.Fields
+	if len(fields) > 0 {
+		fixFieldsConflictAndHandleErrorFields(fields, nil)
+	}
+	for i, column := range f.columns {
+		if i > 0 {
+			buffer.WriteByte(f.delimiter)
+		}
+		switch column {
+		case CsvColumnTime:
+			f.appendCell(buffer, f.opts.formatTime(entry.Time))
+		case CsvColumnLevel:
+			f.appendCell(buffer, entry.Level.String())
+		case CsvColumnTraceId:
+			f.appendCell(buffer, entry.TraceId)
+		case CsvColumnLocation:
+			f.appendCell(buffer, entry.Location)
+		case CsvColumnMessage:
+			f.appendCell(buffer, entry.Message)
+		default:
+			if v, ok := fields[column]; ok && v != nil {
+				f.appendCell(buffer, f.opts.textValue(v))
+			}
+		}
+	}
+	if f.hasExtraColumn() {
+		if len(f.columns) > 0 {
+			buffer.WriteByte(f.delimiter)
+		}
+		var extra map[string]interface{}
+		for k, v := range fields {
+			if f.declared[k] {
+				continue
+			}
+			if extra == nil {
+				extra = make(map[string]interface{}, len(fields))
+			}
+			extra[k] = v
+		}
+		if len(extra) > 0 {
+			var object bytes.Buffer
+			appendJSONFieldObject(&object, extra)
+			f.appendCell(buffer, object.String())
+		}
+	}
+	buffer.WriteByte('\n')
+	return buffer.Bytes(), nil
+}
+
+// appendCell writes the cell value to b, quoted if necessary.
+func (f *csvFormatter) appendCell(b *bytes.Buffer, value string) {
+	if !f.needsQuote(value) {
+		b.WriteString(value)
+		return
+	}
+	b.WriteByte('"')
+	for {
+		i := strings.IndexByte(value, '"')
+		if i < 0 {
+			break
+		}
+		b.WriteString(value[:i+1])
+		b.WriteByte('"')
+		value = value[i+1:]
+	}
+	b.WriteString(value)
+	b.WriteByte('"')
+}
+
+func (f *csvFormatter) needsQuote(value string) bool {
+	if value == "" {
+		return false
+	}
+	if value[0] == ' ' {
+		return true
+	}
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '"', '\r', '\n', f.delimiter:
+			return true
+		}
+	}
+	return false
+}
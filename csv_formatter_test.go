@@ -0,0 +1,185 @@
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCsvFormatter_Format(t *testing.T) {
+	columns := []string{CsvColumnTime, CsvColumnLevel, CsvColumnTraceId, CsvColumnMessage, "user", "amount", "missing"}
+	tests := []struct {
+		formatter Formatter
+		fields    map[string]interface{}
+		want      string
+	}{
+		{
+			NewCsvFormatter(columns),
+			map[string]interface{}{
+				"user":   `say "hi", bye`,
+				"amount": 12.5,
+				"key1":   "a,b",
+				"key2":   testError{},
+			},
+			`2018-05-20 16:20:30.666,info,trace_id_123456789,message 123456789,"say ""hi"", bye",12.5,,` +
+				`"{""key1"":""a,b"",""key2"":""test_error_123456789""}"` + "\n",
+		},
+		{
+			NewCsvFormatter(columns, WithTimeEncoding(TimeEncodingUnixSeconds)),
+			map[string]interface{}{
+				"user":   "line1\nline2",
+				"amount": 1,
+			},
+			"1526804430,info,trace_id_123456789,message 123456789,\"line1\nline2\",1,,\n",
+		},
+		{
+			NewCsvFormatter(columns, WithFieldsNamespace(OmitFieldKey)),
+			map[string]interface{}{
+				"user": " leading space",
+				"key1": "value1",
+			},
+			`2018-05-20 16:20:30.666,info,trace_id_123456789,message 123456789," leading space",,` + "\n",
+		},
+		{
+			NewTsvFormatter(columns),
+			map[string]interface{}{
+				"user":   "a,b",
+				"amount": "c\td",
+			},
+			"2018-05-20 16:20:30.666\tinfo\ttrace_id_123456789\tmessage 123456789\ta,b\t\"c\td\"\t\t\n",
+		},
+	}
+	for _, v := range tests {
+		entry := &Entry{
+			Location: "function(file:line)",
+			Time:     time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+			Level:    InfoLevel,
+			TraceId:  "trace_id_123456789",
+			Message:  "message 123456789",
+			Fields:   v.fields,
+		}
+		data, err := v.formatter.Format(entry)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if have := string(data); have != v.want {
+			t.Errorf("\nhave:%q\nwant:%q", have, v.want)
+			return
+		}
+	}
+}
+
+func TestCsvFormatter_Header(t *testing.T) {
+	tests := []struct {
+		formatter Formatter
+		want      string
+	}{
+		{
+			NewCsvFormatter([]string{CsvColumnTime, CsvColumnLevel, CsvColumnLocation, "user,name"}),
+			"time,level,location,\"user,name\",fields\n",
+		},
+		{
+			NewTsvFormatter([]string{CsvColumnMessage, "user"}, WithFieldsNamespace("extra")),
+			"msg\tuser\textra\n",
+		},
+		{
+			NewCsvFormatter([]string{CsvColumnMessage}, WithFieldsNamespace(OmitFieldKey)),
+			"msg\n",
+		},
+	}
+	for _, v := range tests {
+		formatter, ok := v.formatter.(HeaderFormatter)
+		if !ok {
+			t.Error("want HeaderFormatter")
+			return
+		}
+		if have := string(formatter.Header()); have != v.want {
+			t.Errorf("have:%q, want:%q", have, v.want)
+			return
+		}
+	}
+}
+
+func TestCsvFormatter_WriteHeader(t *testing.T) {
+	formatter := NewCsvFormatter([]string{CsvColumnMessage}).(HeaderFormatter)
+
+	var buffer bytes.Buffer
+	if err := formatter.WriteHeader(&buffer); err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if have, want := buffer.String(), "msg,fields\n"; have != want {
+		t.Errorf("have:%q, want:%q", have, want)
+		return
+	}
+
+	file, err := ioutil.TempFile("", "csv_formatter_test")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	// the header is written only once
+	for i := 0; i < 2; i++ {
+		if err = formatter.WriteHeader(file); err != nil {
+			t.Error(err.Error())
+			return
+		}
+	}
+	data, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if have, want := string(data), "msg,fields\n"; have != want {
+		t.Errorf("have:%q, want:%q", have, want)
+		return
+	}
+}
+
+func TestCsvFormatter_Default(t *testing.T) {
+	entry := &Entry{
+		Location: "function(file:line)",
+		Time:     time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+		Level:    InfoLevel,
+		TraceId:  "trace_id_123456789",
+		Message:  "message 123456789",
+		Fields:   map[string]interface{}{"key1": 1},
+	}
+	tests := []struct {
+		formatter Formatter
+		header    string
+		want      string
+	}{
+		{
+			CsvFormatter,
+			"time,level,request_id,location,msg,fields\n",
+			`2018-05-20 16:20:30.666,info,trace_id_123456789,function(file:line),message 123456789,"{""key1"":1}"` + "\n",
+		},
+		{
+			TsvFormatter,
+			"time\tlevel\trequest_id\tlocation\tmsg\tfields\n",
+			"2018-05-20 16:20:30.666\tinfo\ttrace_id_123456789\tfunction(file:line)\tmessage 123456789\t\"{\"\"key1\"\":1}\"\n",
+		},
+	}
+	for _, v := range tests {
+		if have := string(v.formatter.(HeaderFormatter).Header()); have != v.header {
+			t.Errorf("have:%q, want:%q", have, v.header)
+			return
+		}
+		data, err := v.formatter.Format(entry)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if have := string(data); have != v.want {
+			t.Errorf("\nhave:%q\nwant:%q", have, v.want)
+			return
+		}
+	}
+}
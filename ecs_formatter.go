@@ -0,0 +1,121 @@
+package log
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	internalfields "github.com/chanxuehong/log/internal/fields"
+)
+
+// EcsVersion is the version of Elastic Common Schema which EcsFormatter conforms to.
+const EcsVersion = "1.6.0"
+
+const ecsTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// EcsFormatter formats the Entry as a JSON object which conforms to Elastic Common Schema (ECS).
+//
+// The standard fields are written as @timestamp, log.level, message, trace.id, log.origin.function,
+// log.origin.file.name and log.origin.file.line, the fields of Entry.Fields are nested under
+// the namespace (see WithFieldsNamespace). The first error field (in key order) which has the error context
+// is written as error.message and error.stack_trace.
+var EcsFormatter Formatter = NewEcsFormatter()
+
+// NewEcsFormatter returns a Formatter which formats the Entry as an ECS JSON object.
+func NewEcsFormatter(opts ...FormatterOption) Formatter {
+	return ecsFormatter{opts: newFormatterOptions(opts)}
+}
+
+type ecsFormatter struct {
+	opts *formatterOptions
+}
+
+func (f ecsFormatter) Format(entry *Entry) ([]byte, error) {
+	var buffer *bytes.Buffer
+	if entry.Buffer != nil {
+		buffer = entry.Buffer
+	} else {
+		buffer = bytes.NewBuffer(make([]byte, 0, 16<<10))
+	}
+	var scratch [64]byte
+
+	buffer.WriteByte('{')
+	start := buffer.Len()
+	appendJSONKey(buffer, "@timestamp", start)
+	buffer.WriteByte('"')
+	buffer.Write(entry.Time.UTC().AppendFormat(scratch[:0], ecsTimeLayout))
+	buffer.WriteByte('"')
+	appendJSONKey(buffer, "log.level", start)
+	appendJSONString(buffer, entry.Level.String())
+	appendJSONKey(buffer, "message", start)
+	appendJSONString(buffer, entry.Message)
+	appendJSONKey(buffer, "ecs.version", start)
+	appendJSONString(buffer, EcsVersion)
+	if entry.TraceId != "" {
+		appendJSONKey(buffer, "trace.id", start)
+		appendJSONString(buffer, entry.TraceId)
+	}
+	if function, file, line, ok := splitLocation(entry.Location); ok {
+		if function != "" {
+			appendJSONKey(buffer, "log.origin.function", start)
+			appendJSONString(buffer, function)
+		}
+		appendJSONKey(buffer, "log.origin.file.name", start)
+		appendJSONString(buffer, file)
+		appendJSONKey(buffer, "log.origin.file.line", start)
+		buffer.Write(strconv.AppendInt(scratch[:0], int64(line), 10))
+	}
+	if fields := entry.Fields; len(fields) > 0 {
+		errorKey := ecsErrorKey(fields)
+		fixFieldsConflictAndHandleErrorFields(fields, nil)
+		if errorKey != "" {
+			errorContextKey := errorKey + "_context"
+			appendJSONKey(buffer, "error.message", start)
+			appendJSONString(buffer, textValue(fields[errorKey]))
+			appendJSONKey(buffer, "error.stack_trace", start)
+			appendJSONString(buffer, ecsStackTrace(fields[errorContextKey]))
+			delete(fields, errorKey)
+			delete(fields, errorContextKey)
+		}
+		if len(fields) > 0 {
+			appendJSONKey(buffer, f.opts.fieldsNamespace, start)
+			appendJSONFieldObject(buffer, fields)
+		}
+	}
+	buffer.WriteString("}\n")
+	return buffer.Bytes(), nil
+}
+
+// ecsErrorKey returns the first key (in key order) whose value is an error with the error context,
+// the context may be provided by the errors wrapped by it.
+func ecsErrorKey(fields map[string]interface{}) string {
+	var keys []string
+	for k, v := range fields {
+		err, ok := v.(error)
+		if !ok {
+			continue
+		}
+		if internalfields.ErrorContext(err) != nil {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	sort.Strings(keys)
+	return keys[0]
+}
+
+// ecsStackTrace returns the value of error.stack_trace, the contexts of the error chain are separated by newlines.
+func ecsStackTrace(errorContext interface{}) string {
+	contexts, ok := errorContext.([]interface{})
+	if !ok {
+		return textValue(errorContext)
+	}
+	strs := make([]string, len(contexts))
+	for i, v := range contexts {
+		strs[i] = textValue(v)
+	}
+	return strings.Join(strs, "\n")
+}
@@ -0,0 +1,61 @@
+package log
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEcsFormatter_Format(t *testing.T) {
+	entry := &Entry{
+		Location: "log.testFunc(github.com/chanxuehong/log/ecs_formatter_test.go:20)",
+		Time:     time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+		Level:    ErrorLevel,
+		TraceId:  "trace_id_123456789",
+		Message:  "message_123456789",
+		Fields: map[string]interface{}{
+			"key1":  "fields_value1",
+			"key2":  2,
+			"key3":  testError{},         // error
+			"key4":  testContextError1{}, // error with ErrorContext
+			"key5":  testContextError2{}, // error with ErrorContextJSON
+			"level": "level",
+		},
+		Buffer: nil,
+	}
+	data, err := NewEcsFormatter(WithFieldsNamespace("labels")).Format(entry)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	var have map[string]interface{}
+	if err = json.Unmarshal(data, &have); err != nil {
+		t.Error(err.Error())
+		return
+	}
+	want := map[string]interface{}{
+		"@timestamp":           "2018-05-20T08:20:30.666Z",
+		"log.level":            "error",
+		"message":              "message_123456789",
+		"ecs.version":          EcsVersion,
+		"trace.id":             "trace_id_123456789",
+		"log.origin.function":  "log.testFunc",
+		"log.origin.file.name": "github.com/chanxuehong/log/ecs_formatter_test.go",
+		"log.origin.file.line": float64(20),
+		"error.message":        "context_error1_error_123456789",
+		"error.stack_trace":    "context_error1_context_123456789",
+		"labels": map[string]interface{}{
+			"key1":         "fields_value1",
+			"key2":         float64(2),
+			"key3":         "test_error_123456789",
+			"key5":         "context_error2_error_123456789",
+			"key5_context": map[string]interface{}{"key": "context_error2_context_json_123456789"},
+			"level":        "level",
+		},
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("\nhave:%v\nwant:%v", have, want)
+		return
+	}
+}
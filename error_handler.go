@@ -0,0 +1,100 @@
+package log
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrorPhase is the phase of logging in which the error occurred, see ErrorEvent.
+type ErrorPhase int
+
+const (
+	ErrorPhaseFields   ErrorPhase = iota // failed to combine the fields, the invalid fields are dropped
+	ErrorPhaseFormat                     // failed to format the Entry
+	ErrorPhaseWrite                      // failed to write to the output
+	ErrorPhaseFallback                   // failed to format or write the Entry for the fallback output
+	errorPhaseCount
+)
+
+func (phase ErrorPhase) String() string {
+	switch phase {
+	case ErrorPhaseFields:
+		return "fields"
+	case ErrorPhaseFormat:
+		return "format"
+	case ErrorPhaseWrite:
+		return "write"
+	case ErrorPhaseFallback:
+		return "fallback"
+	default:
+		return fmt.Sprintf("unknown_%d", int(phase))
+	}
+}
+
+// ErrorEvent describes the error which occurred while logging, see WithErrorHandler.
+type ErrorEvent struct {
+	Phase    ErrorPhase
+	Err      error
+	Location string // function(file:line), where the log method was called
+	Entry    *Entry // nil for the fields passed to Logger.WithFields, it must not be retained after the handler returns
+}
+
+var _errorCounts [errorPhaseCount]uint64
+
+// ErrorCount returns the number of errors which occurred in phase since the program started,
+// it is counted for all the Loggers whether or not an error handler is set.
+func ErrorCount(phase ErrorPhase) uint64 {
+	if phase < 0 || phase >= errorPhaseCount {
+		return 0
+	}
+	return atomic.LoadUint64(&_errorCounts[phase])
+}
+
+// handleError counts the error and calls the error handler of opts,
+// the error is written to ConcurrentStderr if there is no error handler.
+func (opts *options) handleError(event ErrorEvent) {
+	if event.Phase >= 0 && event.Phase < errorPhaseCount {
+		atomic.AddUint64(&_errorCounts[event.Phase], 1)
+	}
+	if opts.errorHandler != nil {
+		(*opts.errorHandler)(event)
+		return
+	}
+	var what string
+	switch event.Phase {
+	case ErrorPhaseFields:
+		what = "combine fields"
+	case ErrorPhaseFormat:
+		what = "format Entry"
+	case ErrorPhaseWrite:
+		what = "write to log"
+	default:
+		what = "write to fallback output"
+	}
+	fmt.Fprintf(ConcurrentStderr, "log: failed to %s, error=%v, location=%s\n", what, event.Err, event.Location)
+}
+
+// writeFallback writes entry to the fallback output of opts, data is the entry formatted by opts.formatter,
+// it is nil if the formatting failed.
+func (opts *options) writeFallback(entry *Entry, data []byte) {
+	if opts.fallbackOutput == nil {
+		return
+	}
+	if opts.fallbackFormatter != nil || data == nil {
+		formatter := opts.fallbackFormatter
+		if formatter == nil {
+			formatter = TextFormatter
+		}
+		if entry.Buffer != nil {
+			entry.Buffer.Reset()
+		}
+		var err error
+		if data, err = formatter.Format(entry); err != nil {
+			opts.handleError(ErrorEvent{Phase: ErrorPhaseFallback, Err: err, Location: entry.Location, Entry: entry})
+			return
+		}
+	}
+	if _, err := opts.fallbackOutput.Write(data); err != nil {
+		opts.handleError(ErrorEvent{Phase: ErrorPhaseFallback, Err: err, Location: entry.Location, Entry: entry})
+	}
+}
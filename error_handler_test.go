@@ -0,0 +1,116 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type errorHandlerTestWriter struct{}
+
+func (errorHandlerTestWriter) Write(p []byte) (int, error) { return 0, errors.New("disk full") }
+
+type errorHandlerTestFormatter struct{}
+
+func (errorHandlerTestFormatter) Format(*Entry) ([]byte, error) {
+	return nil, errors.New("format failed")
+}
+
+type errorHandlerTestEvents struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (e *errorHandlerTestEvents) handle(event ErrorEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	msg := ""
+	if event.Entry != nil {
+		msg = event.Entry.Message
+	}
+	e.events = append(e.events, event.Phase.String()+":"+event.Err.Error()+":"+msg)
+}
+
+func (e *errorHandlerTestEvents) String() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return strings.Join(e.events, ",")
+}
+
+func TestWithErrorHandler(t *testing.T) {
+	var events errorHandlerTestEvents
+	writeCount := ErrorCount(ErrorPhaseWrite)
+	fieldsCount := ErrorCount(ErrorPhaseFields)
+
+	lg := New(WithOutput(errorHandlerTestWriter{}), WithErrorHandler(events.handle))
+	lg.Info("msg1", "key")
+	lg.WithFields("key")
+
+	want := "fields:" + _ErrNumberOfFieldsMustNotBeOdd.Error() + ":msg1,write:disk full:msg1," +
+		"fields:" + _ErrNumberOfFieldsMustNotBeOdd.Error() + ":"
+	if have := events.String(); have != want {
+		t.Errorf("\nhave:%s\nwant:%s", have, want)
+		return
+	}
+	if have, want := ErrorCount(ErrorPhaseWrite)-writeCount, uint64(1); have != want {
+		t.Errorf("have:%d, want:%d", have, want)
+		return
+	}
+	if have, want := ErrorCount(ErrorPhaseFields)-fieldsCount, uint64(2); have != want {
+		t.Errorf("have:%d, want:%d", have, want)
+		return
+	}
+}
+
+func TestWithFallbackOutput(t *testing.T) {
+	tests := []struct {
+		formatter         Formatter
+		output            io.Writer
+		fallbackFormatter Formatter
+		want              string
+	}{
+		// the formatted data is written as is
+		{LogfmtFormatter, errorHandlerTestWriter{}, nil, "level=info "},
+		// the Entry is formatted again by the fallback formatter
+		{LogfmtFormatter, errorHandlerTestWriter{}, JsonFormatter, `"level":"info"`},
+		// the Entry is formatted by TextFormatter if the formatter failed
+		{errorHandlerTestFormatter{}, &bytes.Buffer{}, nil, "level=info, "},
+	}
+	for _, v := range tests {
+		var fallback bytes.Buffer
+		lg := New(
+			WithFormatter(v.formatter),
+			WithOutput(v.output),
+			WithErrorHandler(func(ErrorEvent) {}),
+			WithFallbackOutput(&fallback, v.fallbackFormatter),
+		)
+		lg.Info("msg", "key", "value")
+
+		if have := fallback.String(); !strings.Contains(have, v.want) || !strings.Contains(have, "value") {
+			t.Errorf("have:%s, want contains:%s", have, v.want)
+			return
+		}
+	}
+}
+
+func TestErrorPhase_String(t *testing.T) {
+	tests := []struct {
+		phase ErrorPhase
+		want  string
+	}{
+		{ErrorPhaseFields, "fields"},
+		{ErrorPhaseFormat, "format"},
+		{ErrorPhaseWrite, "write"},
+		{ErrorPhaseFallback, "fallback"},
+		{ErrorPhase(100), "unknown_100"},
+	}
+	for _, v := range tests {
+		if have := v.phase.String(); have != v.want {
+			t.Errorf("have:%s, want:%s", have, v.want)
+			return
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ExitFunc is called to exit the program after a Fatal entry is written, see WithFatalExit.
+// It can be replaced in tests.
+var ExitFunc = os.Exit
+
+// ExitHandlerTimeout limits the time for running the exit handlers, see RegisterExitHandler.
+var ExitHandlerTimeout = 5 * time.Second
+
+var (
+	exitHandlersMutex sync.Mutex
+	exitHandlers      []func()
+)
+
+// RegisterExitHandler registers a handler which is called before the program exits because of a Fatal entry,
+// see WithFatalExit. The handlers are called in the order they are registered, the panic of a handler is
+// recovered, and the program exits anyway if the handlers do not return in ExitHandlerTimeout.
+func RegisterExitHandler(handler func()) {
+	if handler == nil {
+		return
+	}
+	exitHandlersMutex.Lock()
+	defer exitHandlersMutex.Unlock()
+	exitHandlers = append(exitHandlers, handler)
+}
+
+// fatalExit flushes output and fallbackOutput (see WithFallbackOutput, it may be nil),
+// runs the exit handlers and then calls ExitFunc with code.
+func fatalExit(output, fallbackOutput io.Writer, code int) {
+	if err := flushWriter(output); err != nil {
+		fmt.Fprintf(ConcurrentStderr, "log: failed to flush output, error=%v\n", err)
+	}
+	if fallbackOutput != nil {
+		if err := flushWriter(fallbackOutput); err != nil {
+			fmt.Fprintf(ConcurrentStderr, "log: failed to flush fallback output, error=%v\n", err)
+		}
+	}
+	runExitHandlers(ExitHandlerTimeout)
+	ExitFunc(code)
+}
+
+func runExitHandlers(timeout time.Duration) {
+	exitHandlersMutex.Lock()
+	handlers := make([]func(), len(exitHandlers))
+	copy(handlers, exitHandlers)
+	exitHandlersMutex.Unlock()
+	if len(handlers) == 0 {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, handler := range handlers {
+			runExitHandler(handler)
+		}
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		fmt.Fprintf(ConcurrentStderr, "log: exit handlers timed out after %v\n", timeout)
+	}
+}
+
+func runExitHandler(handler func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(ConcurrentStderr, "log: exit handler panicked, error=%v\n", r)
+		}
+	}()
+	handler()
+}
+
+// flushWriter flushes w if it has the Flush() error or Sync() error method.
+func flushWriter(w io.Writer) error {
+	switch x := w.(type) {
+	case interface{ Flush() error }:
+		return x.Flush()
+	case interface{ Sync() error }:
+		if err := x.Sync(); err != nil && !isUnsyncableFile(x) {
+			return err
+		}
+	}
+	return nil
+}
+
+// isUnsyncableFile reports whether w is os.Stdout or os.Stderr, which can not be synced if it is a terminal or pipe.
+func isUnsyncableFile(w interface{}) bool {
+	return w == interface{}(os.Stdout) || w == interface{}(os.Stderr)
+}
@@ -0,0 +1,128 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func replaceExitFunc() (codes *[]int, restore func()) {
+	codes = new([]int)
+	old := ExitFunc
+	ExitFunc = func(code int) { *codes = append(*codes, code) }
+	return codes, func() { ExitFunc = old }
+}
+
+func resetExitHandlers() {
+	exitHandlersMutex.Lock()
+	exitHandlers = nil
+	exitHandlersMutex.Unlock()
+}
+
+func TestWithFatalExit(t *testing.T) {
+	codes, restore := replaceExitFunc()
+	defer restore()
+	defer resetExitHandlers()
+
+	var handled []string
+	RegisterExitHandler(func() { handled = append(handled, "handler1") })
+	RegisterExitHandler(func() { panic("handler2") })
+	RegisterExitHandler(func() { handled = append(handled, "handler3") })
+
+	var buffer bytes.Buffer
+	output := bufio.NewWriter(&buffer)
+	lg := New(WithOutput(output), WithFatalExit(2))
+
+	lg.Error("error")
+	if len(*codes) != 0 || len(handled) != 0 || buffer.Len() != 0 {
+		t.Errorf("codes:%v, handled:%v, output:%s, want nothing", *codes, handled, buffer.String())
+		return
+	}
+	lg.Fatal("fatal")
+	if have, want := *codes, []int{2}; len(have) != 1 || have[0] != want[0] {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+	if have, want := strings.Join(handled, ","), "handler1,handler3"; have != want {
+		t.Errorf("have:%s, want:%s", have, want)
+		return
+	}
+	// the output is flushed
+	if have := buffer.String(); !strings.Contains(have, "msg=error") || !strings.Contains(have, "msg=fatal") {
+		t.Errorf("not expected output: %s", have)
+		return
+	}
+}
+
+func TestWithFatalExit_Default(t *testing.T) {
+	codes, restore := replaceExitFunc()
+	defer restore()
+
+	var buffer bytes.Buffer
+	lg := New(WithOutput(&buffer))
+	lg.Fatal("fatal")
+	lg.Output(0, FatalLevel, "fatal")
+	if len(*codes) != 0 {
+		t.Errorf("have:%v, want no exit", *codes)
+		return
+	}
+}
+
+func TestWithFatalExit_FallbackOutput(t *testing.T) {
+	codes, restore := replaceExitFunc()
+	defer restore()
+
+	var buffer bytes.Buffer
+	fallback := bufio.NewWriter(&buffer)
+	lg := New(
+		WithOutput(errorHandlerTestWriter{}),
+		WithFallbackOutput(fallback, nil),
+		WithErrorHandler(func(ErrorEvent) {}),
+		WithFatalExit(2),
+	)
+	lg.Fatal("fatal")
+	if have, want := *codes, []int{2}; len(have) != 1 || have[0] != want[0] {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+	// the fallback output is flushed
+	if have := buffer.String(); !strings.Contains(have, "msg=fatal") {
+		t.Errorf("not expected fallback output: %s", have)
+		return
+	}
+}
+
+func TestRunExitHandlers_Timeout(t *testing.T) {
+	defer resetExitHandlers()
+
+	block := make(chan struct{})
+	defer close(block)
+	RegisterExitHandler(func() { <-block })
+
+	start := time.Now()
+	runExitHandlers(50 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("runExitHandlers took %v, want about 50ms", elapsed)
+		return
+	}
+}
+
+func TestConcurrentWriter_Flush(t *testing.T) {
+	var buffer bytes.Buffer
+	w := ConcurrentWriter(bufio.NewWriter(&buffer))
+	w.Write([]byte("123456789"))
+	if buffer.Len() != 0 {
+		t.Errorf("have:%s, want empty", buffer.String())
+		return
+	}
+	if err := flushWriter(w); err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if have, want := buffer.String(), "123456789"; have != want {
+		t.Errorf("have:%s, want:%s", have, want)
+		return
+	}
+}
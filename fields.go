@@ -1,6 +1,10 @@
 package log
 
-import "errors"
+import (
+	"errors"
+
+	internalfields "github.com/chanxuehong/log/internal/fields"
+)
 
 var (
 	_ErrNumberOfFieldsMustNotBeOdd error = errors.New("the number of fields must not be odd")
@@ -43,3 +47,17 @@ func cloneFields(fields map[string]interface{}) map[string]interface{} {
 	}
 	return m
 }
+
+// fieldKeyStacktrace is the key of the stack trace field, see WithStacktraceLevel.
+const fieldKeyStacktrace = "stacktrace"
+
+// addStacktraceField adds the stack trace field to fields, the field which conflicts with it is renamed,
+// fields may be nil.
+func addStacktraceField(fields map[string]interface{}, stacktrace string) map[string]interface{} {
+	if fields == nil {
+		fields = make(map[string]interface{}, 8)
+	}
+	internalfields.RenameConflict(fields, fieldKeyStacktrace)
+	fields[fieldKeyStacktrace] = stacktrace
+	return fields
+}
@@ -0,0 +1,249 @@
+package log
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultFlattenDepth is the default max depth of the nested values which are flattened, see WithFlattenDepth.
+const defaultFlattenDepth = 5
+
+// flattenCycle is written in place of the value which refers to one of its ancestors.
+const flattenCycle = "<cycle>"
+
+// flattenField calls fn for each leaf of value with the dotted key, for example
+//
+//	flattenField("user", map[string]interface{}{"name": "Alice", "tags": []string{"a", "b"}}, 5, fn)
+//
+// calls fn("user.name", "Alice"), fn("user.tags.0", "a") and fn("user.tags.1", "b").
+//
+// The maps (sorted by key), slices, arrays and exported struct fields are flattened, the struct fields are named
+// the same as encoding/json does. The nested value deeper than maxDepth is passed to fn as a JSON string,
+// and the value which refers to one of its ancestors is passed to fn as "<cycle>".
+func flattenField(key string, value interface{}, maxDepth int, fn func(key string, value interface{})) {
+	if isFlattenLeaf(value) {
+		fn(key, value)
+		return
+	}
+	f := flattener{maxDepth: maxDepth, fn: fn}
+	f.flatten(key, reflect.ValueOf(value), 0)
+}
+
+// flattenFields calls fn for each field of fields sorted by key, the nested values are flattened (see flattenField).
+// The flattened key which conflicts with stdKeys, the other fields or the keys flattened before is renamed
+// the same as renameConflictField does, so the keys passed to fn are unique.
+func flattenFields(fields map[string]interface{}, stdKeys []string, maxDepth int, fn func(key string, value interface{})) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var used map[string]bool // the keys which the flattened keys must not use, created lazily
+	for _, k := range keys {
+		v := fields[k]
+		if isFlattenLeaf(v) {
+			fn(k, v)
+			continue
+		}
+		if used == nil {
+			used = make(map[string]bool, len(stdKeys)+len(fields))
+			for _, key := range stdKeys {
+				used[key] = true
+			}
+			for key, value := range fields {
+				if isFlattenLeaf(value) {
+					used[key] = true
+				}
+			}
+		}
+		flattenField(k, v, maxDepth, func(key string, value interface{}) {
+			if used[key] {
+				newKey := "field." + key
+				key = newKey
+				for i := 2; used[key]; i++ {
+					key = newKey + "." + strconv.Itoa(i)
+				}
+			}
+			used[key] = true
+			fn(key, value)
+		})
+	}
+}
+
+// isFlattenLeaf reports whether value is written as a whole, it is the fast path without reflection.
+func isFlattenLeaf(value interface{}) bool {
+	switch value.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr,
+		float32, float64, complex64, complex128, time.Time, time.Duration, json.RawMessage, []byte:
+		return true
+	case error, fmt.Stringer, encoding.TextMarshaler, json.Marshaler:
+		return true
+	default:
+		return false
+	}
+}
+
+type flattener struct {
+	maxDepth int
+	fn       func(key string, value interface{})
+	path     []uintptr // the pointers of the ancestors, used to detect cycle
+}
+
+func (f *flattener) flatten(key string, v reflect.Value, depth int) {
+	for v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			f.fn(key, nil)
+			return
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		f.fn(key, nil)
+		return
+	}
+	if !v.CanInterface() { // the field promoted from an unexported embedded struct
+		f.fn(key, fmt.Sprint(v))
+		return
+	}
+	value := v.Interface()
+	if isFlattenLeaf(value) {
+		f.fn(key, value)
+		return
+	}
+
+	switch kind := v.Kind(); kind {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			f.fn(key, value)
+			return
+		}
+		if kind == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			f.fn(key, value)
+			return
+		}
+		ptr := v.Pointer()
+		for _, p := range f.path {
+			if p == ptr {
+				f.fn(key, flattenCycle)
+				return
+			}
+		}
+		f.path = append(f.path, ptr)
+		defer func() { f.path = f.path[:len(f.path)-1] }()
+		if kind == reflect.Ptr {
+			f.flatten(key, v.Elem(), depth)
+			return
+		}
+	case reflect.Array, reflect.Struct:
+	default:
+		f.fn(key, value)
+		return
+	}
+
+	if depth >= f.maxDepth {
+		f.fn(key, flattenJSONValue(value))
+		return
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		keys := v.MapKeys()
+		if len(keys) == 0 {
+			f.fn(key, "{}")
+			return
+		}
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			names[i] = textValue(k.Interface())
+		}
+		sort.Sort(mapKeySorter{names: names, keys: keys})
+		for i, k := range keys {
+			f.flatten(key+"."+names[i], v.MapIndex(k), depth+1)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			f.fn(key, "[]")
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			f.flatten(key+"."+strconv.Itoa(i), v.Index(i), depth+1)
+		}
+	case reflect.Struct:
+		if f.flattenStruct(key, v, depth) == 0 {
+			f.fn(key, "{}")
+		}
+	}
+}
+
+// flattenStruct flattens the exported fields of v and returns the number of them,
+// the fields of the embedded structs are promoted the same as encoding/json does.
+func (f *flattener) flattenStruct(key string, v reflect.Value, depth int) (n int) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, tagged := field.Name, false
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if j := strings.IndexByte(tag, ','); j >= 0 {
+				tag = tag[:j]
+			}
+			if tag != "" {
+				name, tagged = tag, true
+			}
+		}
+		fv := v.Field(i)
+		if field.Anonymous && !tagged {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if fv.Kind() == reflect.Ptr {
+					if fv.IsNil() {
+						continue
+					}
+					fv = fv.Elem()
+				}
+				n += f.flattenStruct(key, fv, depth)
+				continue
+			}
+		}
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+		f.flatten(key+"."+name, fv, depth+1)
+		n++
+	}
+	return n
+}
+
+type mapKeySorter struct {
+	names []string
+	keys  []reflect.Value
+}
+
+func (s mapKeySorter) Len() int           { return len(s.names) }
+func (s mapKeySorter) Less(i, j int) bool { return s.names[i] < s.names[j] }
+func (s mapKeySorter) Swap(i, j int) {
+	s.names[i], s.names[j] = s.names[j], s.names[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+// flattenJSONValue returns the JSON encoding of value, or the string "!ERROR: <reason>" if value can not be encoded
+// or its method (for example MarshalJSON) panics, see appendJSONFieldValue.
+func flattenJSONValue(value interface{}) string {
+	var b bytes.Buffer
+	if err := safeAppendJSONValue(&b, value); err != nil {
+		return jsonErrorMarker + err.Error()
+	}
+	return b.String()
+}
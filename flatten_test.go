@@ -0,0 +1,248 @@
+package log
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type flattenTestUser struct {
+	Name    string            `json:"name"`
+	Age     int               `json:"age,omitempty"`
+	Tags    []string          `json:"tags"`
+	Labels  map[string]string `json:"-"`
+	Created time.Time
+	secret  string
+	flattenTestAddress
+	Friend *flattenTestUser `json:"friend"`
+}
+
+type flattenTestAddress struct {
+	City string `json:"city"`
+}
+
+type flattenTestNode struct {
+	Name string
+	Next *flattenTestNode
+}
+
+func flattenString(value interface{}, maxDepth int) string {
+	var pairs []string
+	flattenField("key", value, maxDepth, func(key string, value interface{}) {
+		pairs = append(pairs, key+"="+textValue(value))
+	})
+	return strings.Join(pairs, " ")
+}
+
+func TestFlattenField(t *testing.T) {
+	cycle := &flattenTestNode{Name: "a"}
+	cycle.Next = &flattenTestNode{Name: "b", Next: cycle}
+
+	cycleMap := map[string]interface{}{"a": 1}
+	cycleMap["self"] = cycleMap
+
+	tests := []struct {
+		value    interface{}
+		maxDepth int
+		want     string
+	}{
+		{
+			"value",
+			defaultFlattenDepth,
+			`key=value`,
+		},
+		{
+			map[string]interface{}{"b": []int{2, 3}, "a": 1},
+			defaultFlattenDepth,
+			`key.a=1 key.b.0=2 key.b.1=3`,
+		},
+		{
+			map[int]string{10: "x", 2: "y"},
+			defaultFlattenDepth,
+			`key.10=x key.2=y`,
+		},
+		{
+			flattenTestUser{
+				Name:               "Alice",
+				Tags:               []string{},
+				Labels:             map[string]string{"k": "v"},
+				Created:            time.Date(2018, time.May, 20, 8, 20, 30, 0, time.UTC),
+				secret:             "secret",
+				flattenTestAddress: flattenTestAddress{City: "Paris"},
+				Friend:             &flattenTestUser{Name: "Bob"},
+			},
+			defaultFlattenDepth,
+			`key.name=Alice key.age=0 key.tags=[] key.Created=2018-05-20 16:20:30.000 key.city=Paris ` +
+				`key.friend.name=Bob key.friend.age=0 key.friend.tags=[] key.friend.Created=0001-01-01 08:00:00.000 ` +
+				`key.friend.city= key.friend.friend=<nil>`,
+		},
+		{
+			map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}}},
+			1,
+			`key.a={"b":{"c":1}}`,
+		},
+		{
+			map[string]interface{}{"a": 1},
+			0,
+			`key={"a":1}`,
+		},
+		{
+			cycle,
+			defaultFlattenDepth,
+			`key.Name=a key.Next.Name=b key.Next.Next=<cycle>`,
+		},
+		{
+			cycleMap,
+			defaultFlattenDepth,
+			`key.a=1 key.self=<cycle>`,
+		},
+		{
+			struct{}{},
+			defaultFlattenDepth,
+			`key={}`,
+		},
+		{
+			[]byte("abc"),
+			defaultFlattenDepth,
+			`key=YWJj`,
+		},
+		{
+			testError{},
+			defaultFlattenDepth,
+			`key=test_error_123456789`,
+		},
+	}
+	for _, v := range tests {
+		if have := flattenString(v.value, v.maxDepth); have != v.want {
+			t.Errorf("\nhave:%s\nwant:%s", have, v.want)
+			return
+		}
+	}
+}
+
+func TestTextFormatter_FormatNested(t *testing.T) {
+	entry := &Entry{
+		Time:    time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+		Level:   InfoLevel,
+		Message: "msg",
+		Fields: map[string]interface{}{
+			"user": struct {
+				Name  string `json:"name"`
+				Roles []string
+			}{"Alice", []string{"admin", "dev"}},
+		},
+	}
+	formatter := NewTextFormatter(WithFieldKeys(FieldKeys{TraceId: OmitFieldKey, Location: OmitFieldKey}))
+	data, err := formatter.Format(entry)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	want := `time=2018-05-20 16:20:30.666, level=info, msg=msg, user.name=Alice, user.Roles.0=admin, user.Roles.1=dev` + "\n"
+	if have := string(data); have != want {
+		t.Errorf("\nhave:%s\nwant:%s", have, want)
+		return
+	}
+}
+
+func TestLogfmtFormatter_FormatNested(t *testing.T) {
+	entry := &Entry{
+		Time:    time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+		Level:   InfoLevel,
+		Message: "msg",
+		Fields: map[string]interface{}{
+			"user": map[string]interface{}{"name": "Alice Smith", "address": map[string]string{"city": "Paris"}},
+		},
+	}
+	formatter := NewLogfmtFormatter(
+		WithFieldKeys(FieldKeys{TraceId: OmitFieldKey, Location: OmitFieldKey}),
+		WithFlattenDepth(1),
+	)
+	data, err := formatter.Format(entry)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	want := `time="2018-05-20 16:20:30.666" level=info msg=msg user.address="{\"city\":\"Paris\"}" user.name="Alice Smith"` + "\n"
+	if have := string(data); have != want {
+		t.Errorf("\nhave:%s\nwant:%s", have, want)
+		return
+	}
+}
+
+func TestFormatNested_Conflict(t *testing.T) {
+	newEntry := func() *Entry {
+		return &Entry{
+			Time:    time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+			Level:   InfoLevel,
+			Message: "msg",
+			Fields: map[string]interface{}{
+				"user.name":       "literal",
+				"field.user.name": "renamed",
+				"user":            map[string]interface{}{"name": "Alice"},
+				"a":               map[string]interface{}{"b": map[string]interface{}{"c": 1}},
+				"a.b":             map[string]interface{}{"c": 2},
+				"log":             map[string]interface{}{"msg": "nested"},
+			},
+		}
+	}
+	opts := []FormatterOption{
+		WithFieldKeys(FieldKeys{Time: OmitFieldKey, Level: OmitFieldKey, TraceId: OmitFieldKey, Location: OmitFieldKey, Message: "log.msg"}),
+	}
+	tests := []struct {
+		formatter Formatter
+		want      string
+	}{
+		{
+			NewTextFormatter(opts...),
+			`log.msg=msg, a.b.c=1, field.a.b.c=2, field.user.name=renamed, field.log.msg=nested, ` +
+				`field.user.name.2=Alice, user.name=literal` + "\n",
+		},
+		{
+			NewLogfmtFormatter(opts...),
+			`log.msg=msg a.b.c=1 field.a.b.c=2 field.user.name=renamed field.log.msg=nested ` +
+				`field.user.name.2=Alice user.name=literal` + "\n",
+		},
+	}
+	for _, v := range tests {
+		data, err := v.formatter.Format(newEntry())
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if have := string(data); have != v.want {
+			t.Errorf("\nhave:%s\nwant:%s", have, v.want)
+			return
+		}
+	}
+}
+
+func TestFlattenFormatter_FormatBadValue(t *testing.T) {
+	newEntry := func() *Entry {
+		return &Entry{
+			Level:   InfoLevel,
+			Message: "msg",
+			Fields: map[string]interface{}{
+				"n": map[string]interface{}{"m": map[string]interface{}{"x": testPanicMarshaler{}}},
+			},
+		}
+	}
+	tests := []struct {
+		formatter Formatter
+		want      string
+	}{
+		{NewTextFormatter(WithFlattenDepth(1)), "n.m=!ERROR: panic: marshal_panic_123456789\n"},
+		{NewLogfmtFormatter(WithFlattenDepth(0)), `n="!ERROR: panic: marshal_panic_123456789"` + "\n"},
+	}
+	for _, v := range tests {
+		have, err := v.formatter.Format(newEntry())
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if !strings.HasSuffix(string(have), v.want) {
+			t.Errorf("have:%s, want suffix:%s", have, v.want)
+			return
+		}
+	}
+}
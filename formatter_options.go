@@ -0,0 +1,341 @@
+package log
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	internalfields "github.com/chanxuehong/log/internal/fields"
+)
+
+var _hostname, _ = os.Hostname()
+
+// TimeEncoding specifies how the built-in formatters encode Entry.Time.
+type TimeEncoding int
+
+const (
+	TimeEncodingDefault     TimeEncoding = iota // "2006-01-02 15:04:05.000", see FormatTime
+	TimeEncodingRFC3339                         // "2006-01-02T15:04:05Z07:00"
+	TimeEncodingRFC3339Nano                     // "2006-01-02T15:04:05.999999999Z07:00"
+	TimeEncodingUnixSeconds                     // seconds since the Unix epoch
+	TimeEncodingUnixMillis                      // milliseconds since the Unix epoch
+	TimeEncodingUnixNanos                       // nanoseconds since the Unix epoch
+)
+
+func isValidTimeEncoding(enc TimeEncoding) bool {
+	return enc >= TimeEncodingDefault && enc <= TimeEncodingUnixNanos
+}
+
+// FormatterOption configures the formatters created by NewTextFormatter, NewJsonFormatter, NewLogfmtFormatter and so on.
+//
+// Every constructor accepts all the options, but each option is used only by the following formatters,
+// the other constructors ignore it:
+//
+//	WithTimeLocation      NewTextFormatter, NewJsonFormatter, NewLogfmtFormatter, NewConsoleFormatter,
+//	WithTimeEncoding        NewTemplateFormatter, NewCsvFormatter, NewTsvFormatter
+//	WithFieldKeys         NewTextFormatter, NewJsonFormatter, NewLogfmtFormatter, NewConsoleFormatter
+//	WithOmitEmptyTraceId  NewTextFormatter, NewJsonFormatter, NewLogfmtFormatter (NewConsoleFormatter always omits it)
+//	WithColor             NewConsoleFormatter, NewTemplateFormatter
+//	WithFieldsNamespace   NewEcsFormatter, NewCsvFormatter, NewTsvFormatter
+//	WithHostname          NewGelfFormatter
+//	WithProjectId         NewGoogleCloudFormatter
+//	WithResource          NewOtelFormatter
+//	WithFlattenDepth      NewTextFormatter, NewLogfmtFormatter
+//	WithBytesEncoding     NewTextFormatter, NewLogfmtFormatter, NewConsoleFormatter, NewCsvFormatter, NewTsvFormatter
+//
+// NewEcsFormatter, NewGoogleCloudFormatter, NewGelfFormatter and NewOtelFormatter write the time, the standard fields
+// and the trace id as their formats require, so WithTimeLocation, WithTimeEncoding, WithFieldKeys and
+// WithOmitEmptyTraceId do not change their output.
+type FormatterOption func(*formatterOptions)
+
+// WithTimeLocation sets the location in which Entry.Time is formatted, for example time.UTC or time.Local.
+// The default location is Asia/Shanghai (UTC+8).
+func WithTimeLocation(loc *time.Location) FormatterOption {
+	return func(o *formatterOptions) {
+		if loc == nil {
+			return
+		}
+		o.timeLocation = loc
+	}
+}
+
+// WithTimeEncoding sets the encoding of Entry.Time, the default is TimeEncodingDefault.
+func WithTimeEncoding(enc TimeEncoding) FormatterOption {
+	return func(o *formatterOptions) {
+		if !isValidTimeEncoding(enc) {
+			return
+		}
+		o.timeEncoding = enc
+	}
+}
+
+// OmitFieldKey is the key in FieldKeys which means the standard field is omitted.
+const OmitFieldKey = "-"
+
+// FieldKeys specifies the keys of the standard fields.
+// An empty key means the default key, and OmitFieldKey means the field is not written.
+type FieldKeys struct {
+	Time     string // default "time"
+	Level    string // default "level"
+	TraceId  string // default "request_id"
+	Location string // default "location"
+	Message  string // default "msg"
+}
+
+// WithFieldKeys sets the keys of the standard fields,
+// the fields of Entry.Fields which conflict with these keys are renamed to "field." + key.
+func WithFieldKeys(keys FieldKeys) FormatterOption {
+	return func(o *formatterOptions) {
+		o.keys = keys
+	}
+}
+
+// WithOmitEmptyTraceId omits the trace id field when Entry.TraceId is empty.
+func WithOmitEmptyTraceId(omit bool) FormatterOption {
+	return func(o *formatterOptions) {
+		o.omitEmptyTraceId = omit
+	}
+}
+
+// WithColor enables or disables the color of ConsoleFormatter and the template formatter (see NewTemplateFormatter),
+// it overrides the automatic detection.
+func WithColor(enable bool) FormatterOption {
+	return func(o *formatterOptions) {
+		if enable {
+			o.color = colorEnabled
+		} else {
+			o.color = colorDisabled
+		}
+	}
+}
+
+// WithFieldsNamespace sets the key of the object which the fields of Entry.Fields are nested under,
+// the default is "fields". It is used by EcsFormatter, and by CsvFormatter as the header of
+// the trailing JSON column of the undeclared fields (OmitFieldKey drops the column).
+func WithFieldsNamespace(namespace string) FormatterOption {
+	return func(o *formatterOptions) {
+		if namespace == "" {
+			return
+		}
+		o.fieldsNamespace = namespace
+	}
+}
+
+// WithHostname sets the hostname which is written by GelfFormatter, the default is os.Hostname().
+func WithHostname(hostname string) FormatterOption {
+	return func(o *formatterOptions) {
+		if hostname == "" {
+			return
+		}
+		o.hostname = hostname
+	}
+}
+
+// WithProjectId sets the Google Cloud project id, which is used by GoogleCloudFormatter
+// to write the trace as "projects/<projectId>/traces/<traceId>".
+func WithProjectId(projectId string) FormatterOption {
+	return func(o *formatterOptions) {
+		o.projectId = projectId
+	}
+}
+
+// WithResource sets the resource attributes (for example service.name) which are used by OtelFormatter,
+// if it is set, each Entry is wrapped in resourceLogs.
+func WithResource(attributes map[string]interface{}) FormatterOption {
+	return func(o *formatterOptions) {
+		o.resource = attributes
+	}
+}
+
+// WithFlattenDepth sets the max depth of the nested maps, slices and structs which are flattened into dotted keys
+// (for example user.name=Alice) by TextFormatter and LogfmtFormatter, the deeper values are written as JSON.
+// The default is 5, and 0 means the nested values are written as JSON without flattening.
+func WithFlattenDepth(depth int) FormatterOption {
+	return func(o *formatterOptions) {
+		if depth < 0 {
+			return
+		}
+		o.flattenDepth = depth
+	}
+}
+
+// WithBytesEncoding sets the encoding of the []byte values written by TextFormatter, LogfmtFormatter, ConsoleFormatter
+// and CsvFormatter, the default is BytesEncodingBase64.
+func WithBytesEncoding(enc BytesEncoding) FormatterOption {
+	return func(o *formatterOptions) {
+		if enc != BytesEncodingBase64 && enc != BytesEncodingHex {
+			return
+		}
+		o.bytesEncoding = enc
+	}
+}
+
+type colorMode int
+
+const (
+	colorAuto colorMode = iota
+	colorEnabled
+	colorDisabled
+)
+
+type formatterOptions struct {
+	timeLocation     *time.Location
+	timeEncoding     TimeEncoding
+	keys             FieldKeys
+	omitEmptyTraceId bool
+	color            colorMode
+	fieldsNamespace  string
+	hostname         string
+	projectId        string
+	resource         map[string]interface{}
+	flattenDepth     int
+	bytesEncoding    BytesEncoding
+
+	stdKeys     []string                   // the keys of the standard fields which are written, used to fix conflict
+	textEncoder internalfields.TextEncoder // see textValue
+}
+
+func newFormatterOptions(opts []FormatterOption) *formatterOptions {
+	o := formatterOptions{
+		flattenDepth: defaultFlattenDepth,
+	}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(&o)
+	}
+	if o.timeLocation == nil {
+		o.timeLocation = _beijingLocation
+	}
+	if o.fieldsNamespace == "" {
+		o.fieldsNamespace = "fields"
+	}
+	if o.hostname == "" {
+		o.hostname = _hostname
+	}
+	o.keys.Time = fixFieldKey(o.keys.Time, fieldKeyTime)
+	o.keys.Level = fixFieldKey(o.keys.Level, fieldKeyLevel)
+	o.keys.TraceId = fixFieldKey(o.keys.TraceId, fieldKeyTraceId)
+	o.keys.Location = fixFieldKey(o.keys.Location, fieldKeyLocation)
+	o.keys.Message = fixFieldKey(o.keys.Message, fieldKeyMessage)
+	o.stdKeys = make([]string, 0, len(stdFieldKeys))
+	for _, key := range [...]string{o.keys.Time, o.keys.Level, o.keys.TraceId, o.keys.Location, o.keys.Message} {
+		if key == OmitFieldKey {
+			continue
+		}
+		o.stdKeys = append(o.stdKeys, key)
+	}
+	o.textEncoder = internalfields.TextEncoder{
+		HexBytes:   o.bytesEncoding == BytesEncodingHex,
+		FormatTime: o.formatTime,
+	}
+	return &o
+}
+
+func fixFieldKey(key, defaultKey string) string {
+	if key == "" {
+		return defaultKey
+	}
+	return key
+}
+
+// hasTime, hasLevel, hasTraceId, hasLocation and hasMessage report whether the standard field should be written.
+
+func (o *formatterOptions) hasTime() bool {
+	return o.keys.Time != OmitFieldKey
+}
+func (o *formatterOptions) hasLevel() bool {
+	return o.keys.Level != OmitFieldKey
+}
+func (o *formatterOptions) hasTraceId(traceId string) bool {
+	if o.keys.TraceId == OmitFieldKey {
+		return false
+	}
+	return traceId != "" || !o.omitEmptyTraceId
+}
+func (o *formatterOptions) hasLocation() bool {
+	return o.keys.Location != OmitFieldKey
+}
+func (o *formatterOptions) hasMessage() bool {
+	return o.keys.Message != OmitFieldKey
+}
+
+// useColor reports whether the color should be written when the output is unknown,
+// the automatic color is disabled unless the Logger reports its output is a terminal, see colorFormatter.
+func (o *formatterOptions) useColor() bool {
+	return o.color == colorEnabled
+}
+
+// autoColor reports whether the color is automatically detected by the output of the Logger.
+func (o *formatterOptions) autoColor() bool {
+	return o.color == colorAuto
+}
+
+// isNumericTime reports whether the time is encoded as a number.
+func (o *formatterOptions) isNumericTime() bool {
+	switch o.timeEncoding {
+	case TimeEncodingUnixSeconds, TimeEncodingUnixMillis, TimeEncodingUnixNanos:
+		return true
+	default:
+		return false
+	}
+}
+
+// appendUnixTime appends t as a number since the Unix epoch, it is meaningful only if isNumericTime returns true.
+func (o *formatterOptions) appendUnixTime(b []byte, t time.Time) []byte {
+	switch o.timeEncoding {
+	case TimeEncodingUnixSeconds:
+		return strconv.AppendInt(b, t.Unix(), 10)
+	case TimeEncodingUnixMillis:
+		return strconv.AppendInt(b, unixMillis(t), 10)
+	default:
+		return appendUnixNanos(b, t)
+	}
+}
+
+// unixMillis returns the milliseconds since the Unix epoch of t, rounded down.
+// It is computed from t.Unix() and t.Nanosecond() since t.UnixNano() overflows for the zero time.
+func unixMillis(t time.Time) int64 {
+	return t.Unix()*1e3 + int64(t.Nanosecond()/1e6)
+}
+
+// appendUnixNanos appends the nanoseconds since the Unix epoch of t. The number does not fit in int64
+// for the times before 1678 or after 2262 (for example the zero time), so it is written from t.Unix() and
+// t.Nanosecond() instead of t.UnixNano().
+func appendUnixNanos(b []byte, t time.Time) []byte {
+	sec, nsec := t.Unix(), int64(t.Nanosecond())
+	if sec < 0 {
+		// sec*1e9 + nsec == -((-sec-1)*1e9 + (1e9-nsec))
+		b = append(b, '-')
+		sec = -sec
+		if nsec > 0 {
+			sec, nsec = sec-1, 1e9-nsec
+		}
+	}
+	if sec == 0 {
+		return strconv.AppendInt(b, nsec, 10)
+	}
+	b = strconv.AppendInt(b, sec, 10)
+	var scratch [16]byte
+	digits := strconv.AppendInt(scratch[:0], nsec, 10)
+	for i := len(digits); i < 9; i++ {
+		b = append(b, '0')
+	}
+	return append(b, digits...)
+}
+
+// formatTime returns the string form of t.
+func (o *formatterOptions) formatTime(t time.Time) string {
+	switch o.timeEncoding {
+	case TimeEncodingRFC3339:
+		return t.In(o.timeLocation).Format(time.RFC3339)
+	case TimeEncodingRFC3339Nano:
+		return t.In(o.timeLocation).Format(time.RFC3339Nano)
+	case TimeEncodingUnixSeconds, TimeEncodingUnixMillis, TimeEncodingUnixNanos:
+		var scratch [32]byte
+		return string(o.appendUnixTime(scratch[:0], t))
+	default:
+		return FormatTime(t.In(o.timeLocation))
+	}
+}
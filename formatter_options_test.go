@@ -0,0 +1,262 @@
+package log
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatterOptions_FormatTime(t *testing.T) {
+	tm := time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC)
+	tests := []struct {
+		opts []FormatterOption
+		want string
+	}{
+		{
+			nil,
+			"2018-05-20 16:20:30.666",
+		},
+		{
+			[]FormatterOption{WithTimeLocation(time.UTC)},
+			"2018-05-20 08:20:30.666",
+		},
+		{
+			[]FormatterOption{WithTimeLocation(nil), WithTimeEncoding(TimeEncoding(100))},
+			"2018-05-20 16:20:30.666",
+		},
+		{
+			[]FormatterOption{WithTimeEncoding(TimeEncodingRFC3339)},
+			"2018-05-20T16:20:30+08:00",
+		},
+		{
+			[]FormatterOption{WithTimeLocation(time.UTC), WithTimeEncoding(TimeEncodingRFC3339Nano)},
+			"2018-05-20T08:20:30.666777888Z",
+		},
+		{
+			[]FormatterOption{WithTimeEncoding(TimeEncodingUnixSeconds)},
+			"1526804430",
+		},
+		{
+			[]FormatterOption{WithTimeEncoding(TimeEncodingUnixMillis)},
+			"1526804430666",
+		},
+		{
+			[]FormatterOption{WithTimeEncoding(TimeEncodingUnixNanos)},
+			"1526804430666777888",
+		},
+	}
+	for _, v := range tests {
+		have := newFormatterOptions(v.opts).formatTime(tm)
+		if have != v.want {
+			t.Errorf("have:%s, want:%s", have, v.want)
+			return
+		}
+	}
+}
+
+func TestFormatterOptions_FormatTimeOverflow(t *testing.T) {
+	tests := []struct {
+		time   time.Time
+		millis string
+		nanos  string
+	}{
+		{time.Time{}, "-62135596800000", "-62135596800000000000"},
+		{time.Date(3000, time.January, 1, 0, 0, 0, 5, time.UTC), "32503680000000", "32503680000000000005"},
+		{time.Unix(0, 0), "0", "0"},
+		{time.Unix(0, 1), "0", "1"},
+		{time.Unix(1, 5), "1000", "1000000005"},
+		{time.Unix(-1, 999999999), "-1", "-1"},
+		{time.Unix(-2, 500000000), "-1500", "-1500000000"},
+	}
+	millisOpts := newFormatterOptions([]FormatterOption{WithTimeEncoding(TimeEncodingUnixMillis)})
+	nanosOpts := newFormatterOptions([]FormatterOption{WithTimeEncoding(TimeEncodingUnixNanos)})
+	for _, v := range tests {
+		if have := millisOpts.formatTime(v.time); have != v.millis {
+			t.Errorf("time:%v, have:%s, want:%s", v.time, have, v.millis)
+			return
+		}
+		if have := nanosOpts.formatTime(v.time); have != v.nanos {
+			t.Errorf("time:%v, have:%s, want:%s", v.time, have, v.nanos)
+			return
+		}
+	}
+}
+
+func TestNewTextFormatter(t *testing.T) {
+	formatter := NewTextFormatter(WithTimeLocation(time.UTC), WithTimeEncoding(TimeEncodingRFC3339))
+	data, err := formatter.Format(&Entry{
+		Time:    time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+		Level:   InfoLevel,
+		Message: "msg",
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	want := "time=2018-05-20T08:20:30Z, "
+	if have := string(data); !strings.HasPrefix(have, want) {
+		t.Errorf("have:%s, want prefix:%s", have, want)
+		return
+	}
+}
+
+func TestNewJsonFormatter(t *testing.T) {
+	formatter := NewJsonFormatter(WithTimeEncoding(TimeEncodingUnixMillis))
+	data, err := formatter.Format(&Entry{
+		Time:    time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+		Level:   InfoLevel,
+		Message: "msg",
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	var have struct {
+		Time json.Number `json:"time"`
+	}
+	if err = json.Unmarshal(data, &have); err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if want := json.Number("1526804430666"); have.Time != want {
+		t.Errorf("have:%s, want:%s", have.Time, want)
+		return
+	}
+}
+
+func TestWithFieldKeys(t *testing.T) {
+	formatter := NewTextFormatter(
+		WithFieldKeys(FieldKeys{
+			Time:     "ts",
+			Level:    "severity",
+			TraceId:  "trace_id",
+			Location: OmitFieldKey,
+			Message:  "message",
+		}),
+		WithOmitEmptyTraceId(true),
+	)
+	entry := &Entry{
+		Location: "function(file:line)",
+		Time:     time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+		Level:    InfoLevel,
+		Message:  "message_123456789",
+		Fields: map[string]interface{}{
+			"ts":       "ts",
+			"message":  "message",
+			"location": "location",
+			"msg":      "msg",
+		},
+	}
+	data, err := formatter.Format(entry)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	want := `ts=2018-05-20 16:20:30.666, severity=info, message=message_123456789, ` +
+		`field.message=message, field.ts=ts, location=location, msg=msg` + "\n"
+	if have := string(data); have != want {
+		t.Errorf("\nhave:%s\nwant:%s", have, want)
+		return
+	}
+}
+
+// csvHeaderFormatter writes the header before the row, since WithFieldsNamespace changes only the header.
+type csvHeaderFormatter struct {
+	HeaderFormatter
+}
+
+func (f csvHeaderFormatter) Format(entry *Entry) ([]byte, error) {
+	data, err := f.HeaderFormatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(f.Header()), data...), nil
+}
+
+// TestFormatterOption_Constructors checks the constructors which honor the formatter-specific options,
+// see the document of FormatterOption.
+func TestFormatterOption_Constructors(t *testing.T) {
+	newEntry := func(traceId string) *Entry {
+		return &Entry{
+			Location: "function(file:line)",
+			Time:     time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+			Level:    WarnLevel,
+			TraceId:  traceId,
+			Message:  "message_123456789",
+			Fields: map[string]interface{}{
+				"bytes":  []byte("abc"),
+				"nested": map[string]interface{}{"a": map[string]interface{}{"b": 1}},
+			},
+		}
+	}
+	constructors := map[string]func(opts ...FormatterOption) Formatter{
+		"NewTextFormatter":        NewTextFormatter,
+		"NewJsonFormatter":        NewJsonFormatter,
+		"NewLogfmtFormatter":      NewLogfmtFormatter,
+		"NewConsoleFormatter":     NewConsoleFormatter,
+		"NewEcsFormatter":         NewEcsFormatter,
+		"NewGoogleCloudFormatter": NewGoogleCloudFormatter,
+		"NewGelfFormatter":        NewGelfFormatter,
+		"NewOtelFormatter":        NewOtelFormatter,
+		"NewTemplateFormatter": func(opts ...FormatterOption) Formatter {
+			return MustNewTemplateFormatter(`{{formatTime .Time}} {{levelColor .Level .Level}} {{.Message}}`, opts...)
+		},
+		"NewCsvFormatter": func(opts ...FormatterOption) Formatter {
+			return csvHeaderFormatter{NewCsvFormatter([]string{CsvColumnTime, CsvColumnMessage, "bytes"}, opts...).(HeaderFormatter)}
+		},
+		"NewTsvFormatter": func(opts ...FormatterOption) Formatter {
+			return csvHeaderFormatter{NewTsvFormatter([]string{CsvColumnTime, CsvColumnMessage, "bytes"}, opts...).(HeaderFormatter)}
+		},
+	}
+	tests := []struct {
+		name    string
+		option  FormatterOption
+		honors  []string
+		traceId string
+	}{
+		{"WithTimeLocation", WithTimeLocation(time.UTC), []string{
+			"NewTextFormatter", "NewJsonFormatter", "NewLogfmtFormatter", "NewConsoleFormatter",
+			"NewTemplateFormatter", "NewCsvFormatter", "NewTsvFormatter",
+		}, "trace_id_123456789"},
+		{"WithTimeEncoding", WithTimeEncoding(TimeEncodingUnixMillis), []string{
+			"NewTextFormatter", "NewJsonFormatter", "NewLogfmtFormatter", "NewConsoleFormatter",
+			"NewTemplateFormatter", "NewCsvFormatter", "NewTsvFormatter",
+		}, "trace_id_123456789"},
+		{"WithFieldKeys", WithFieldKeys(FieldKeys{Time: "ts", Location: OmitFieldKey}), []string{
+			"NewTextFormatter", "NewJsonFormatter", "NewLogfmtFormatter", "NewConsoleFormatter",
+		}, "trace_id_123456789"},
+		{"WithOmitEmptyTraceId", WithOmitEmptyTraceId(true), []string{"NewTextFormatter", "NewJsonFormatter", "NewLogfmtFormatter"}, ""},
+		{"WithColor", WithColor(true), []string{"NewConsoleFormatter", "NewTemplateFormatter"}, "trace_id_123456789"},
+		{"WithFieldsNamespace", WithFieldsNamespace("namespace"), []string{"NewEcsFormatter", "NewCsvFormatter", "NewTsvFormatter"}, "trace_id_123456789"},
+		{"WithHostname", WithHostname("hostname.example"), []string{"NewGelfFormatter"}, "trace_id_123456789"},
+		{"WithProjectId", WithProjectId("project"), []string{"NewGoogleCloudFormatter"}, "trace_id_123456789"},
+		{"WithResource", WithResource(map[string]interface{}{"service.name": "test"}), []string{"NewOtelFormatter"}, "trace_id_123456789"},
+		{"WithFlattenDepth", WithFlattenDepth(0), []string{"NewTextFormatter", "NewLogfmtFormatter"}, "trace_id_123456789"},
+		{"WithBytesEncoding", WithBytesEncoding(BytesEncodingHex), []string{
+			"NewTextFormatter", "NewLogfmtFormatter", "NewConsoleFormatter", "NewCsvFormatter", "NewTsvFormatter",
+		}, "trace_id_123456789"},
+	}
+	for _, v := range tests {
+		honors := make(map[string]bool, len(v.honors))
+		for _, name := range v.honors {
+			honors[name] = true
+		}
+		for name, constructor := range constructors {
+			without, err := constructor().Format(newEntry(v.traceId))
+			if err != nil {
+				t.Error(err.Error())
+				return
+			}
+			with, err := constructor(v.option).Format(newEntry(v.traceId))
+			if err != nil {
+				t.Error(err.Error())
+				return
+			}
+			if have, want := string(with) != string(without), honors[name]; have != want {
+				t.Errorf("option:%s, constructor:%s, have:%t, want:%t", v.name, name, have, want)
+				return
+			}
+		}
+	}
+}
@@ -2,34 +2,13 @@ package log
 
 import (
 	"encoding/json"
+	"fmt"
 	"reflect"
+	"runtime"
+	"strings"
 	"testing"
 )
 
-func TestFixFieldsConflict(t *testing.T) {
-	m := map[string]interface{}{
-		"request_id":    "request_id",
-		"time":          "time",
-		"field.time":    "field.time",
-		"level":         "level",
-		"field.level":   "field.level",
-		"field.level.2": "field.level.2",
-	}
-	fixFieldsConflict(m, []string{"request_id", "field.level", "field.level.3"})
-	want := map[string]interface{}{
-		"field.request_id":    "request_id",
-		"field.time.2":        "time",
-		"field.time":          "field.time",
-		"field.field.level.3": "level",
-		"field.field.level":   "field.level",
-		"field.level.2":       "field.level.2",
-	}
-	if !reflect.DeepEqual(m, want) {
-		t.Errorf("\nhave:%v\nwant:%v", m, want)
-		return
-	}
-}
-
 func TestFixFieldsConflictAndHandleErrorFields(t *testing.T) {
 	var nilErr error
 	fields := map[string]interface{}{
@@ -43,7 +22,7 @@ func TestFixFieldsConflictAndHandleErrorFields(t *testing.T) {
 		"context_not_error":      testContextWithoutError{X: "test"}, // not error with ErrorContext and ErrorContextJSON
 		"context_error1_context": "context_error1_context_value",     // conflict with context_error1.context
 	}
-	fixFieldsConflictAndHandleErrorFields(fields)
+	fixFieldsConflictAndHandleErrorFields(fields, stdFieldKeys)
 	want := map[string]interface{}{
 		"user_id":                      123456,
 		"name":                         "jack",
@@ -92,6 +71,127 @@ func (testContextError3) ErrorContextJSON() json.RawMessage {
 	return []byte(`{"key":"context_error3_context_json_123456789"}`)
 }
 
+// testJoinError wraps multiple errors, like the error returned by errors.Join.
+type testJoinError []error
+
+func (e testJoinError) Error() string   { return "join_error_123456789" }
+func (e testJoinError) Unwrap() []error { return e }
+
+func TestFixFieldsConflictAndHandleErrorFields_Chain(t *testing.T) {
+	wrapped := fmt.Errorf("wrap: %w", testContextError1{})
+	fields := map[string]interface{}{
+		"wrapped":       wrapped,
+		"joined":        testJoinError{fmt.Errorf("wrap: %w", testContextError2{}), testError{}, testContextError1{}},
+		"wrapped_chain": "wrapped_chain_value", // conflict with wrapped.chain
+	}
+	fixFieldsConflictAndHandleErrorFields(fields, stdFieldKeys)
+	want := map[string]interface{}{
+		"wrapped":         "wrap: context_error1_error_123456789",
+		"wrapped_context": "context_error1_context_123456789",
+		"wrapped_chain": []interface{}{
+			map[string]interface{}{"msg": "wrap: context_error1_error_123456789", "type": "*fmt.wrapError"},
+			map[string]interface{}{"msg": "context_error1_error_123456789", "type": "log.testContextError1"},
+		},
+		"joined": "join_error_123456789",
+		"joined_context": []interface{}{
+			json.RawMessage(`{"key":"context_error2_context_json_123456789"}`),
+			"context_error1_context_123456789",
+		},
+		"joined_chain": []interface{}{
+			map[string]interface{}{"msg": "join_error_123456789", "type": "log.testJoinError"},
+			map[string]interface{}{"msg": "wrap: context_error2_error_123456789", "type": "*fmt.wrapError"},
+			map[string]interface{}{"msg": "context_error2_error_123456789", "type": "log.testContextError2"},
+			map[string]interface{}{"msg": "test_error_123456789", "type": "log.testError"},
+			map[string]interface{}{"msg": "context_error1_error_123456789", "type": "log.testContextError1"},
+		},
+		"field.wrapped_chain": "wrapped_chain_value",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("\nhave:%v\nwant:%v", fields, want)
+		return
+	}
+}
+
+type testPanicError struct{}
+
+func (testPanicError) Error() string { panic("error_panic_123456789") }
+
+type testWrapPanicError struct{}
+
+func (testWrapPanicError) Error() string { return "wrap_panic_error_123456789" }
+func (testWrapPanicError) Unwrap() error { return testPanicError{} }
+
+func TestFixFieldsConflictAndHandleErrorFields_ErrorPanic(t *testing.T) {
+	fields := map[string]interface{}{
+		"err":     testPanicError{},
+		"wrapped": testWrapPanicError{},
+	}
+	fixFieldsConflictAndHandleErrorFields(fields, stdFieldKeys)
+	want := map[string]interface{}{
+		"err":       "",
+		"err_error": "panic: error_panic_123456789",
+		"wrapped":   "wrap_panic_error_123456789",
+		"wrapped_chain": []interface{}{
+			map[string]interface{}{"msg": "wrap_panic_error_123456789", "type": "log.testWrapPanicError"},
+			map[string]interface{}{"msg": "!ERROR: panic: error_panic_123456789", "type": "log.testPanicError"},
+		},
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("\nhave:%v\nwant:%v", fields, want)
+		return
+	}
+}
+
+type testStackError struct {
+	pcs []uintptr
+}
+
+func newTestStackError() testStackError {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(1, pcs)
+	return testStackError{pcs: pcs[:n]}
+}
+
+func (testStackError) Error() string            { return "stack_error_123456789" }
+func (e testStackError) StackFrames() []uintptr { return e.pcs }
+
+type testLogStackError struct{}
+
+func (testLogStackError) Error() string    { return "log_stack_error_123456789" }
+func (testLogStackError) LogStack() string { return "log_stack_123456789" }
+
+func TestFixFieldsConflictAndHandleErrorFields_Stack(t *testing.T) {
+	fields := map[string]interface{}{
+		"stack_error":     newTestStackError(),
+		"log_stack_error": fmt.Errorf("wrap: %w", testLogStackError{}),
+		"error":           testError{},
+	}
+	fixFieldsConflictAndHandleErrorFields(fields, stdFieldKeys)
+
+	if have, want := fields["log_stack_error_stack"], "log_stack_123456789"; have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+	if _, ok := fields["error_stack"]; ok {
+		t.Error("want no error_stack field")
+		return
+	}
+	stack, _ := fields["stack_error_stack"].(string)
+	frames := strings.Split(stack, "\n")
+	if len(frames) < 2 {
+		t.Errorf("not expected stack: %s", stack)
+		return
+	}
+	if !strings.HasPrefix(frames[0], "log.newTestStackError(") || !strings.Contains(frames[0], "/log/formatter_test.go:") {
+		t.Errorf("not expected frame: %s", frames[0])
+		return
+	}
+	if !strings.HasPrefix(frames[1], "log.TestFixFieldsConflictAndHandleErrorFields_Stack(") {
+		t.Errorf("not expected frame: %s", frames[1])
+		return
+	}
+}
+
 type testContextWithoutError struct {
 	X string `json:"x"`
 }
@@ -0,0 +1,130 @@
+package log
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	gcpFieldKeySeverity       = "severity"
+	gcpFieldKeyMessage        = "message"
+	gcpFieldKeyTime           = "time"
+	gcpFieldKeyTrace          = "logging.googleapis.com/trace"
+	gcpFieldKeySourceLocation = "logging.googleapis.com/sourceLocation"
+)
+
+// gcpFieldKeys are the keys which are recognized by the Google Cloud Logging agent.
+var gcpFieldKeys = []string{
+	gcpFieldKeySeverity,
+	gcpFieldKeyMessage,
+	gcpFieldKeyTime,
+	gcpFieldKeyTrace,
+	gcpFieldKeySourceLocation,
+	"logging.googleapis.com/spanId",
+	"logging.googleapis.com/labels",
+	"logging.googleapis.com/insertId",
+	"logging.googleapis.com/operation",
+	"httpRequest",
+}
+
+// GoogleCloudFormatter formats the Entry as a JSON object which is recognized by the Google Cloud Logging agent.
+//
+// The Level is mapped to severity DEBUG, INFO, WARNING, ERROR or CRITICAL, Entry.Time is written as time
+// in RFC3339Nano UTC, Entry.TraceId is written as logging.googleapis.com/trace (see WithProjectId),
+// Entry.Location is written as logging.googleapis.com/sourceLocation, and the fields of Entry.Fields are written at the top level.
+var GoogleCloudFormatter Formatter = NewGoogleCloudFormatter()
+
+// NewGoogleCloudFormatter returns a Formatter which formats the Entry as a Google Cloud Logging JSON object.
+func NewGoogleCloudFormatter(opts ...FormatterOption) Formatter {
+	return gcpFormatter{opts: newFormatterOptions(opts)}
+}
+
+type gcpFormatter struct {
+	opts *formatterOptions
+}
+
+func (f gcpFormatter) Format(entry *Entry) ([]byte, error) {
+	var buffer *bytes.Buffer
+	if entry.Buffer != nil {
+		buffer = entry.Buffer
+	} else {
+		buffer = bytes.NewBuffer(make([]byte, 0, 16<<10))
+	}
+	var scratch [64]byte
+
+	buffer.WriteByte('{')
+	start := buffer.Len()
+	appendJSONKey(buffer, gcpFieldKeySeverity, start)
+	appendJSONString(buffer, gcpSeverity(entry.Level))
+	appendJSONKey(buffer, gcpFieldKeyMessage, start)
+	appendJSONString(buffer, entry.Message)
+	appendJSONKey(buffer, gcpFieldKeyTime, start)
+	buffer.WriteByte('"')
+	buffer.Write(entry.Time.UTC().AppendFormat(scratch[:0], time.RFC3339Nano))
+	buffer.WriteByte('"')
+	if entry.TraceId != "" {
+		appendJSONKey(buffer, gcpFieldKeyTrace, start)
+		if projectId := f.opts.projectId; projectId != "" {
+			appendJSONString(buffer, "projects/"+projectId+"/traces/"+entry.TraceId)
+		} else {
+			appendJSONString(buffer, entry.TraceId)
+		}
+	}
+	if function, file, line, ok := splitLocation(entry.Location); ok {
+		appendJSONKey(buffer, gcpFieldKeySourceLocation, start)
+		buffer.WriteByte('{')
+		locationStart := buffer.Len()
+		appendJSONKey(buffer, "file", locationStart)
+		appendJSONString(buffer, file)
+		appendJSONKey(buffer, "line", locationStart)
+		appendJSONString(buffer, strconv.Itoa(line))
+		if function != "" {
+			appendJSONKey(buffer, "function", locationStart)
+			appendJSONString(buffer, function)
+		}
+		buffer.WriteByte('}')
+	}
+	if fields := entry.Fields; len(fields) > 0 {
+		fixFieldsConflictAndHandleErrorFields(fields, gcpFieldKeys)
+
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			appendJSONKey(buffer, k, start)
+			appendJSONFieldValue(buffer, fields[k])
+		}
+	}
+	buffer.WriteString("}\n")
+	return buffer.Bytes(), nil
+}
+
+// gcpSeverity returns the Google Cloud Logging severity of the level,
+// which is mapped from the syslog severity of the level, see Level.SyslogSeverity.
+func gcpSeverity(level Level) string {
+	if !isValidLevel(level) {
+		return "DEFAULT"
+	}
+	switch level.SyslogSeverity() {
+	case 0:
+		return "EMERGENCY"
+	case 1:
+		return "ALERT"
+	case 2:
+		return "CRITICAL"
+	case 3:
+		return "ERROR"
+	case 4:
+		return "WARNING"
+	case 5:
+		return "NOTICE"
+	case 6:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
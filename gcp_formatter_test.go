@@ -0,0 +1,76 @@
+package log
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGoogleCloudFormatter_Format(t *testing.T) {
+	entry := &Entry{
+		Location: "log.testFunc(github.com/chanxuehong/log/gcp_formatter_test.go:20)",
+		Time:     time.Date(2018, time.May, 20, 16, 20, 30, 666777888, _beijingLocation),
+		Level:    WarnLevel,
+		TraceId:  "trace_id_123456789",
+		Message:  "message_123456789",
+		Fields: map[string]interface{}{
+			"key1":     "fields_value1",
+			"key2":     2,
+			"key3":     testContextError2{}, // error with ErrorContextJSON
+			"severity": "severity",
+		},
+		Buffer: nil,
+	}
+	data, err := NewGoogleCloudFormatter(WithProjectId("my-project")).Format(entry)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	var have map[string]interface{}
+	if err = json.Unmarshal(data, &have); err != nil {
+		t.Error(err.Error())
+		return
+	}
+	want := map[string]interface{}{
+		"severity":                     "WARNING",
+		"message":                      "message_123456789",
+		"time":                         "2018-05-20T08:20:30.666777888Z",
+		"logging.googleapis.com/trace": "projects/my-project/traces/trace_id_123456789",
+		"logging.googleapis.com/sourceLocation": map[string]interface{}{
+			"file":     "github.com/chanxuehong/log/gcp_formatter_test.go",
+			"line":     "20",
+			"function": "log.testFunc",
+		},
+		"key1":           "fields_value1",
+		"key2":           float64(2),
+		"key3":           "context_error2_error_123456789",
+		"key3_context":   map[string]interface{}{"key": "context_error2_context_json_123456789"},
+		"field.severity": "severity",
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("\nhave:%v\nwant:%v", have, want)
+		return
+	}
+}
+
+func TestGcpSeverity(t *testing.T) {
+	tests := []struct {
+		level    Level
+		severity string
+	}{
+		{FatalLevel, "CRITICAL"},
+		{ErrorLevel, "ERROR"},
+		{WarnLevel, "WARNING"},
+		{InfoLevel, "INFO"},
+		{DebugLevel, "DEBUG"},
+		{TraceLevel, "DEBUG"},
+		{100, "DEFAULT"},
+	}
+	for _, v := range tests {
+		if have := gcpSeverity(v.level); have != v.severity {
+			t.Errorf("level:%v, have:%s, want:%s", v.level, have, v.severity)
+			return
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package log
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// GelfVersion is the version of Graylog Extended Log Format which GelfFormatter conforms to.
+const GelfVersion = "1.1"
+
+// gelfFieldKeys are the keys of the additional fields (without the '_' prefix) which are written by GelfFormatter,
+// "id" is reserved by GELF.
+var gelfFieldKeys = []string{
+	"id",
+	fieldKeyTraceId,
+	"function",
+	"file",
+	"line",
+}
+
+// GelfFormatter formats the Entry as a GELF 1.1 JSON object, see GelfWriter to send it to Graylog.
+//
+// The Level is mapped to the syslog severity, Entry.TraceId is written as _request_id,
+// Entry.Location is written as _function, _file and _line, and the fields of Entry.Fields
+// are written as the additional fields with the '_' prefix.
+var GelfFormatter Formatter = NewGelfFormatter()
+
+// NewGelfFormatter returns a Formatter which formats the Entry as a GELF 1.1 JSON object.
+func NewGelfFormatter(opts ...FormatterOption) Formatter {
+	return gelfFormatter{opts: newFormatterOptions(opts)}
+}
+
+type gelfFormatter struct {
+	opts *formatterOptions
+}
+
+func (f gelfFormatter) Format(entry *Entry) ([]byte, error) {
+	var buffer *bytes.Buffer
+	if entry.Buffer != nil {
+		buffer = entry.Buffer
+	} else {
+		buffer = bytes.NewBuffer(make([]byte, 0, 16<<10))
+	}
+	var scratch [64]byte
+
+	buffer.WriteByte('{')
+	start := buffer.Len()
+	appendJSONKey(buffer, "version", start)
+	appendJSONString(buffer, GelfVersion)
+	appendJSONKey(buffer, "host", start)
+	appendJSONString(buffer, f.opts.hostname)
+	appendJSONKey(buffer, "short_message", start)
+	appendJSONString(buffer, entry.Message)
+	appendJSONKey(buffer, "timestamp", start)
+	// UnixNano overflows for the zero time, and the remainder is negative before 1970
+	millis := unixMillis(entry.Time)
+	if millis < 0 {
+		buffer.WriteByte('-')
+		millis = -millis
+	}
+	buffer.Write(strconv.AppendInt(scratch[:0], millis/1e3, 10))
+	buffer.WriteByte('.')
+	millisecond := millis % 1e3
+	buffer.WriteByte(digits01[millisecond/100])
+	buffer.WriteByte(digits10[millisecond%100])
+	buffer.WriteByte(digits01[millisecond%100])
+	appendJSONKey(buffer, "level", start)
+	buffer.Write(strconv.AppendInt(scratch[:0], int64(entry.Level.SyslogSeverity()), 10))
+	if entry.TraceId != "" {
+		appendJSONKey(buffer, "_"+fieldKeyTraceId, start)
+		appendJSONString(buffer, entry.TraceId)
+	}
+	if function, file, line, ok := splitLocation(entry.Location); ok {
+		if function != "" {
+			appendJSONKey(buffer, "_function", start)
+			appendJSONString(buffer, function)
+		}
+		appendJSONKey(buffer, "_file", start)
+		appendJSONString(buffer, file)
+		appendJSONKey(buffer, "_line", start)
+		buffer.Write(strconv.AppendInt(scratch[:0], int64(line), 10))
+	}
+	if fields := entry.Fields; len(fields) > 0 {
+		fixFieldsConflictAndHandleErrorFields(fields, gelfFieldKeys)
+
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			appendJSONKey(buffer, gelfFieldKey(k), start)
+			appendGelfValue(buffer, fields[k])
+		}
+	}
+	buffer.WriteString("}\n")
+	return buffer.Bytes(), nil
+}
+
+// gelfFieldKey returns the additional field name of key, the characters which are not allowed
+// by GELF (other than letters, digits, '_', '.' and '-') are replaced with '_'.
+func gelfFieldKey(key string) string {
+	b := make([]byte, 0, len(key)+1)
+	b = append(b, '_')
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '.', c == '-':
+			b = append(b, c)
+		default:
+			b = append(b, '_')
+		}
+	}
+	return string(b)
+}
+
+// appendGelfValue writes the additional field value to b, GELF allows only string and number values.
+func appendGelfValue(b *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		appendJSONValue(b, v)
+		return
+	case float32:
+		if !math.IsInf(float64(v), 0) && !math.IsNaN(float64(v)) {
+			appendJSONValue(b, v)
+			return
+		}
+	case float64:
+		if !math.IsInf(v, 0) && !math.IsNaN(v) {
+			appendJSONValue(b, v)
+			return
+		}
+	}
+	appendJSONString(b, textValue(value))
+}
@@ -0,0 +1,99 @@
+package log
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGelfFormatter_Format(t *testing.T) {
+	entry := &Entry{
+		Location: "log.testFunc(github.com/chanxuehong/log/gelf_formatter_test.go:20)",
+		Time:     time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+		Level:    WarnLevel,
+		TraceId:  "trace_id_123456789",
+		Message:  "message_123456789",
+		Fields: map[string]interface{}{
+			"key1":     "fields_value1",
+			"key2":     2,
+			"key 3":    3.5,
+			"key4":     true,
+			"key5":     math.NaN(),
+			"key6":     testContextError1{}, // error with ErrorContext
+			"id":       "id",
+			"file":     "file",
+			"location": "location",
+		},
+		Buffer: nil,
+	}
+	data, err := NewGelfFormatter(WithHostname("example.com")).Format(entry)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	var have map[string]interface{}
+	if err = json.Unmarshal(data, &have); err != nil {
+		t.Error(err.Error())
+		return
+	}
+	want := map[string]interface{}{
+		"version":       "1.1",
+		"host":          "example.com",
+		"short_message": "message_123456789",
+		"timestamp":     1526804430.666,
+		"level":         float64(4),
+		"_request_id":   "trace_id_123456789",
+		"_function":     "log.testFunc",
+		"_file":         "github.com/chanxuehong/log/gelf_formatter_test.go",
+		"_line":         float64(20),
+		"_key1":         "fields_value1",
+		"_key2":         float64(2),
+		"_key_3":        3.5,
+		"_key4":         "true",
+		"_key5":         "NaN",
+		"_key6":         "context_error1_error_123456789",
+		"_key6_context": "context_error1_context_123456789",
+		"_field.id":     "id",
+		"_field.file":   "file",
+		"_location":     "location",
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("\nhave:%v\nwant:%v", have, want)
+		return
+	}
+}
+
+func TestGelfFormatter_Format_Timestamp(t *testing.T) {
+	tests := []struct {
+		time time.Time
+		want string
+	}{
+		{time.Date(2018, time.May, 20, 8, 20, 30, 5777888, time.UTC), `"timestamp":1526804430.005`},
+		{time.Unix(0, 0), `"timestamp":0.000`},
+		{time.Date(1969, time.December, 31, 23, 59, 58, 500000000, time.UTC), `"timestamp":-1.500`},
+		{time.Unix(-1, 999999999), `"timestamp":-0.001`},
+		{time.Time{}, `"timestamp":-62135596800.000`},
+	}
+	for _, v := range tests {
+		entry := &Entry{
+			Time:  v.time,
+			Level: InfoLevel,
+		}
+		data, err := NewGelfFormatter(WithHostname("example.com")).Format(entry)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if !strings.Contains(string(data), v.want) {
+			t.Errorf("time:%v, have:%s, want:%s", v.time, data, v.want)
+			return
+		}
+		if !json.Valid(data) {
+			t.Errorf("time:%v, invalid JSON:%s", v.time, data)
+			return
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// GelfDefaultChunkSize is the default size of the UDP datagram, which is suitable for WAN.
+	GelfDefaultChunkSize = 1420
+
+	gelfChunkHeaderSize = 12
+	gelfMaxChunkCount   = 128
+)
+
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+var (
+	_ErrGelfMessageTooLarge = errors.New("log: the GELF message is too large")
+	_ErrGelfWriterClosed    = errors.New("log: the GelfWriter is closed")
+)
+
+// GelfWriterOption configures the GelfWriter.
+type GelfWriterOption func(*GelfWriter)
+
+// WithGelfCompression enables or disables the gzip compression of the UDP messages, the default is disabled.
+// The TCP messages are never compressed since GELF does not support it.
+func WithGelfCompression(enable bool) GelfWriterOption {
+	return func(w *GelfWriter) {
+		w.compress = enable
+	}
+}
+
+// WithGelfChunkSize sets the maximum size of the UDP datagram, the default is GelfDefaultChunkSize.
+func WithGelfChunkSize(size int) GelfWriterOption {
+	return func(w *GelfWriter) {
+		if size <= gelfChunkHeaderSize {
+			return
+		}
+		w.chunkSize = size
+	}
+}
+
+// WithGelfDialTimeout sets the timeout of dialing the Graylog server, the default is 5 seconds.
+func WithGelfDialTimeout(timeout time.Duration) GelfWriterOption {
+	return func(w *GelfWriter) {
+		if timeout <= 0 {
+			return
+		}
+		w.dialTimeout = timeout
+	}
+}
+
+// GelfWriter is a thread-safe io.Writer which sends the GELF messages (see GelfFormatter) to Graylog.
+//
+// Over UDP, the message which is larger than the chunk size is split into GELF chunks, and it is optionally gzip compressed.
+// Over TCP, the messages are framed by the null byte, and the connection is re-established on the next Write if it is broken.
+type GelfWriter struct {
+	network     string
+	address     string
+	compress    bool
+	chunkSize   int
+	dialTimeout time.Duration
+
+	messageIdPrefix uint32
+	messageIdSeq    uint32
+
+	mu     sync.Mutex // protects the following fields
+	conn   net.Conn
+	closed bool
+}
+
+// NewGelfWriter returns a GelfWriter which sends the GELF messages to address over network,
+// network must be "udp", "udp4", "udp6", "tcp", "tcp4" or "tcp6".
+func NewGelfWriter(network, address string, opts ...GelfWriterOption) (*GelfWriter, error) {
+	switch network {
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6":
+	default:
+		return nil, errors.New("log: unsupported network for GELF: " + network)
+	}
+	w := &GelfWriter{
+		network:     network,
+		address:     address,
+		chunkSize:   GelfDefaultChunkSize,
+		dialTimeout: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(w)
+	}
+	var prefix [4]byte
+	if _, err := rand.Read(prefix[:]); err != nil {
+		return nil, err
+	}
+	w.messageIdPrefix = binary.BigEndian.Uint32(prefix[:])
+
+	conn, err := w.dial()
+	if err != nil {
+		return nil, err
+	}
+	w.conn = conn
+	return w, nil
+}
+
+func (w *GelfWriter) isUDP() bool {
+	return w.network[0] == 'u'
+}
+
+func (w *GelfWriter) dial() (net.Conn, error) {
+	return net.DialTimeout(w.network, w.address, w.dialTimeout)
+}
+
+// Write sends p as a GELF message, the trailing newline of p is removed.
+func (w *GelfWriter) Write(p []byte) (n int, err error) {
+	n = len(p)
+	if i := len(p) - 1; i >= 0 && p[i] == '\n' {
+		p = p[:i]
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, _ErrGelfWriterClosed
+	}
+	if w.conn == nil {
+		if w.conn, err = w.dial(); err != nil {
+			return 0, err
+		}
+	}
+	if w.isUDP() {
+		err = w.writeUDP(p)
+	} else {
+		err = w.writeTCP(p)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (w *GelfWriter) writeTCP(p []byte) error {
+	message := make([]byte, len(p)+1)
+	copy(message, p)
+	if _, err := w.conn.Write(message); err != nil {
+		// re-establish the connection on the next Write
+		w.conn.Close()
+		w.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (w *GelfWriter) writeUDP(p []byte) error {
+	if w.compress {
+		var buffer bytes.Buffer
+		zw := gzip.NewWriter(&buffer)
+		if _, err := zw.Write(p); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		p = buffer.Bytes()
+	}
+	if len(p) <= w.chunkSize {
+		_, err := w.conn.Write(p)
+		return err
+	}
+
+	dataSize := w.chunkSize - gelfChunkHeaderSize
+	count := (len(p) + dataSize - 1) / dataSize
+	if count > gelfMaxChunkCount {
+		return _ErrGelfMessageTooLarge
+	}
+	var messageId [8]byte
+	binary.BigEndian.PutUint32(messageId[:4], w.messageIdPrefix)
+	binary.BigEndian.PutUint32(messageId[4:], atomic.AddUint32(&w.messageIdSeq, 1))
+
+	chunk := make([]byte, 0, w.chunkSize)
+	for i := 0; i < count; i++ {
+		data := p[i*dataSize:]
+		if len(data) > dataSize {
+			data = data[:dataSize]
+		}
+		chunk = append(chunk[:0], gelfChunkMagic...)
+		chunk = append(chunk, messageId[:]...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, data...)
+		if _, err := w.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the underlying connection, the subsequent Write returns an error.
+func (w *GelfWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
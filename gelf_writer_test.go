@@ -0,0 +1,141 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGelfWriter_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer conn.Close()
+
+	w, err := NewGelfWriter("udp", conn.LocalAddr().String(), WithGelfCompression(true), WithGelfChunkSize(64))
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer w.Close()
+
+	// random-like content which is not compressed too small
+	var message bytes.Buffer
+	for i := 0; i < 100; i++ {
+		message.WriteString(FormatTime(time.Unix(int64(i)*7919, int64(i)*104729)))
+	}
+	if _, err = w.Write(append(message.Bytes(), '\n')); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var (
+		chunks [][]byte
+		count  int
+	)
+	for {
+		packet := make([]byte, 128)
+		n, _, err := conn.ReadFrom(packet)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		packet = packet[:n]
+		if n > 64 {
+			t.Errorf("chunk size %d exceeds 64", n)
+			return
+		}
+		if !bytes.HasPrefix(packet, gelfChunkMagic) {
+			t.Error("want chunked message")
+			return
+		}
+		if count == 0 {
+			count = int(packet[11])
+			chunks = make([][]byte, count)
+		}
+		chunks[packet[10]] = packet[gelfChunkHeaderSize:]
+		if count--; count == 0 {
+			break
+		}
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(bytes.Join(chunks, nil)))
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	have, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if !bytes.Equal(have, message.Bytes()) {
+		t.Errorf("\nhave:%s\nwant:%s", have, message.Bytes())
+		return
+	}
+}
+
+func TestGelfWriter_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer ln.Close()
+
+	messages := make(chan string, 2)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			message, err := r.ReadString(0)
+			if err != nil {
+				return
+			}
+			messages <- message
+		}
+	}()
+
+	w, err := NewGelfWriter("tcp", ln.Addr().String())
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer w.Close()
+
+	for _, message := range []string{`{"short_message":"1"}` + "\n", `{"short_message":"2"}`} {
+		if _, err = w.Write([]byte(message)); err != nil {
+			t.Error(err.Error())
+			return
+		}
+	}
+	for _, want := range []string{`{"short_message":"1"}` + "\x00", `{"short_message":"2"}` + "\x00"} {
+		select {
+		case have := <-messages:
+			if have != want {
+				t.Errorf("have:%q, want:%q", have, want)
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("timeout")
+			return
+		}
+	}
+
+	w.Close()
+	if _, err = w.Write([]byte("x")); err == nil || !strings.Contains(err.Error(), "closed") {
+		t.Errorf("want closed error, have:%v", err)
+		return
+	}
+}
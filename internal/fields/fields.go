@@ -0,0 +1,198 @@
+// Package fields implements the field handling which is shared by the log package and its subpackages
+// (for example syslog), without adding it to the public API of the log package.
+package fields
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Normalize prepares the fields of log.Entry for a Formatter, the same way as the built-in formatters do.
+//
+// The fields which conflict with reservedKeys are renamed to "field." + key,
+// the error values are replaced with their messages, and the error context (returned by the ErrorContext
+// or ErrorContextJSON method of the error or the errors wrapped by it) is added as the "<key>_context" field.
+// If the error wraps other errors, the messages and type names of them are added as the "<key>_chain" field.
+// If the error (or the error wrapped by it) records the stack trace, that is it has the StackFrames() []uintptr
+// or LogStack() string method, the stack trace is added as the "<key>_stack" field.
+// The ObjectMarshaler values are resolved into map[string]interface{}, and the error returned by
+// MarshalLogObject (or the panic in it or in the Error method) is added as the "<key>_error" field.
+// It modifies fields in place.
+func Normalize(fields map[string]interface{}, reservedKeys []string) {
+	var (
+		errorFields    map[string]interface{} // the <key>_context, <key>_chain, <key>_stack and <key>_error fields
+		errorFieldKeys []string
+	)
+	addErrorField := func(key string, value interface{}) {
+		if errorFields == nil {
+			errorFields = make(map[string]interface{}, 8)
+		}
+		errorFields[key] = value
+		errorFieldKeys = append(errorFieldKeys, key)
+	}
+	for k, v := range fields {
+		errorValue, ok := v.(error)
+		if !ok {
+			if marshaler, ok := v.(ObjectMarshaler); ok {
+				object, err := MarshalObject(marshaler, 1)
+				fields[k] = object
+				if err != nil {
+					addErrorField(k+"_error", err.Error())
+				}
+			}
+			continue
+		}
+		msg, err := errorMessage(errorValue)
+		fields[k] = msg
+		if err != nil {
+			addErrorField(k+"_error", err.Error())
+		}
+		chain := errorChain(errorValue)
+		if errorContext := errorChainContext(chain); errorContext != nil {
+			addErrorField(k+"_context", errorContext)
+		}
+		if len(chain) > 1 {
+			addErrorField(k+"_chain", errorChainField(chain))
+		}
+		if stack := errorChainStack(chain); stack != "" {
+			addErrorField(k+"_stack", stack)
+		}
+	}
+	FixConflict(fields, reservedKeys, errorFieldKeys)
+	for k, v := range errorFields {
+		fields[k] = v
+	}
+}
+
+// FixConflict renames the fields which conflict with stdKeys and fieldKeys,
+// the renamed key is "field." + key, and a numeric suffix is appended if it is still conflict.
+func FixConflict(fields map[string]interface{}, stdKeys []string, fieldKeys []string) {
+	for _, fieldKey := range stdKeys {
+		RenameConflict(fields, fieldKey)
+	}
+	for _, fieldKey := range fieldKeys {
+		RenameConflict(fields, fieldKey)
+	}
+}
+
+// RenameConflict renames the field fieldKey if it exists, see FixConflict.
+func RenameConflict(fields map[string]interface{}, fieldKey string) {
+	fieldValue, ok := fields[fieldKey]
+	if !ok {
+		return
+	}
+	delete(fields, fieldKey)
+	newKey := "field." + fieldKey
+	for key, i := newKey, 2; ; i++ {
+		if _, ok = fields[key]; !ok {
+			fields[key] = fieldValue
+			return
+		}
+		key = newKey + "." + strconv.Itoa(i)
+	}
+}
+
+// errorMessage returns err.Error(), a panic in the Error method is recovered and returned as panicErr.
+func errorMessage(err error) (msg string, panicErr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg, panicErr = "", PanicError(r)
+		}
+	}()
+	return err.Error(), nil
+}
+
+// maxErrorChainLength limits the errors which are collected by errorChain.
+const maxErrorChainLength = 32
+
+// errorChain returns err and the errors wrapped by it in depth-first order,
+// the wrapped errors are returned by the Unwrap() error or Unwrap() []error method.
+func errorChain(err error) []error {
+	chain := []error{err}
+	for i := 0; i < len(chain) && len(chain) < maxErrorChainLength; i++ {
+		var wrapped []error
+		switch x := chain[i].(type) {
+		case interface{ Unwrap() error }:
+			if e := x.Unwrap(); e != nil {
+				wrapped = []error{e}
+			}
+		case interface{ Unwrap() []error }:
+			wrapped = x.Unwrap()
+		}
+		if len(wrapped) == 0 {
+			continue
+		}
+		// insert the wrapped errors just after chain[i] to keep the depth-first order
+		rest := append(wrapped[:len(wrapped):len(wrapped)], chain[i+1:]...)
+		chain = append(chain[:i+1], rest...)
+	}
+	if len(chain) > maxErrorChainLength {
+		chain = chain[:maxErrorChainLength]
+	}
+	return chain
+}
+
+// ErrorContext returns the error context of err and the errors wrapped by it, see errorChainContext.
+func ErrorContext(err error) interface{} {
+	return errorChainContext(errorChain(err))
+}
+
+// errorChainContext returns the error context of the errors in chain,
+// the context is returned by the ErrorContextJSON or ErrorContext method of the error.
+// It returns nil if no error has the context, the context itself if only one error has it,
+// or the []interface{} of the contexts in chain order.
+func errorChainContext(chain []error) interface{} {
+	var contexts []interface{}
+	for _, err := range chain {
+		switch x := err.(type) {
+		case interface{ ErrorContextJSON() json.RawMessage }:
+			contexts = append(contexts, x.ErrorContextJSON())
+		case interface{ ErrorContext() string }:
+			contexts = append(contexts, x.ErrorContext())
+		}
+	}
+	switch len(contexts) {
+	case 0:
+		return nil
+	case 1:
+		return contexts[0]
+	default:
+		return contexts
+	}
+}
+
+// errorChainStack returns the stack trace recorded by the errors in chain, the innermost one is used
+// since it is normally the nearest to where the error occurred. The program counters returned by
+// the StackFrames method are formatted by FormatStacktrace.
+func errorChainStack(chain []error) string {
+	for i := len(chain) - 1; i >= 0; i-- {
+		switch x := chain[i].(type) {
+		case interface{ StackFrames() []uintptr }:
+			if stack := FormatStacktrace(x.StackFrames()); stack != "" {
+				return stack
+			}
+		case interface{ LogStack() string }:
+			if stack := x.LogStack(); stack != "" {
+				return stack
+			}
+		}
+	}
+	return ""
+}
+
+// errorChainField returns the value of the <key>_chain field, that is the messages and type names of the errors in chain.
+func errorChainField(chain []error) []interface{} {
+	field := make([]interface{}, len(chain))
+	for i, err := range chain {
+		msg, panicErr := errorMessage(err)
+		if panicErr != nil {
+			msg = ErrorMarker + panicErr.Error()
+		}
+		field[i] = map[string]interface{}{
+			"msg":  msg,
+			"type": fmt.Sprintf("%T", err),
+		}
+	}
+	return field
+}
@@ -0,0 +1,43 @@
+package fields
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestFixConflict(t *testing.T) {
+	m := map[string]interface{}{
+		"request_id":    "request_id",
+		"time":          "time",
+		"field.time":    "field.time",
+		"level":         "level",
+		"field.level":   "field.level",
+		"field.level.2": "field.level.2",
+	}
+	FixConflict(m, []string{"time", "level", "request_id", "location", "msg"}, []string{"request_id", "field.level", "field.level.3"})
+	want := map[string]interface{}{
+		"field.request_id":    "request_id",
+		"field.time.2":        "time",
+		"field.time":          "field.time",
+		"field.field.level.3": "level",
+		"field.field.level":   "field.level",
+		"field.level.2":       "field.level.2",
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("\nhave:%v\nwant:%v", m, want)
+		return
+	}
+}
+
+func TestErrorChain_Limit(t *testing.T) {
+	err := errors.New("test_error_123456789")
+	for i := 0; i < 2*maxErrorChainLength; i++ {
+		err = fmt.Errorf("wrap: %w", err)
+	}
+	if have := len(errorChain(err)); have != maxErrorChainLength {
+		t.Errorf("have:%d, want:%d", have, maxErrorChainLength)
+		return
+	}
+}
@@ -0,0 +1,93 @@
+package fields
+
+import (
+	"errors"
+	"reflect"
+	"time"
+)
+
+// ObjectMarshaler is implemented by the types which write their own fields, see log.ObjectMarshaler.
+type ObjectMarshaler interface {
+	MarshalLogObject(enc FieldEncoder) error
+}
+
+// FieldEncoder is used by ObjectMarshaler to add the fields, see log.FieldEncoder.
+type FieldEncoder interface {
+	AddString(key, value string)
+	AddInt64(key string, value int64)
+	AddUint64(key string, value uint64)
+	AddFloat64(key string, value float64)
+	AddBool(key string, value bool)
+	AddTime(key string, value time.Time)
+	AddDuration(key string, value time.Duration)
+
+	// AddObject adds the nested object, the error returned by MarshalLogObject is returned.
+	AddObject(key string, value ObjectMarshaler) error
+
+	// Add adds the value of any other type, it is written the same as the field value of log.Entry.Fields.
+	Add(key string, value interface{})
+}
+
+// maxObjectMarshalerDepth limits the nested objects, see ErrObjectTooDeep.
+const maxObjectMarshalerDepth = 32
+
+// ErrObjectTooDeep is returned by MarshalObject if the objects are nested deeper than maxObjectMarshalerDepth.
+var ErrObjectTooDeep = errors.New("the objects are nested too deep")
+
+// mapFieldEncoder is the FieldEncoder which adds the fields to a map.
+type mapFieldEncoder struct {
+	fields map[string]interface{}
+	depth  int
+}
+
+var _ FieldEncoder = (*mapFieldEncoder)(nil)
+
+func (enc *mapFieldEncoder) AddString(key, value string)          { enc.fields[key] = value }
+func (enc *mapFieldEncoder) AddInt64(key string, value int64)     { enc.fields[key] = value }
+func (enc *mapFieldEncoder) AddUint64(key string, value uint64)   { enc.fields[key] = value }
+func (enc *mapFieldEncoder) AddFloat64(key string, value float64) { enc.fields[key] = value }
+func (enc *mapFieldEncoder) AddBool(key string, value bool)       { enc.fields[key] = value }
+func (enc *mapFieldEncoder) AddTime(key string, value time.Time)  { enc.fields[key] = value }
+
+func (enc *mapFieldEncoder) AddDuration(key string, value time.Duration) { enc.fields[key] = value }
+
+func (enc *mapFieldEncoder) AddObject(key string, value ObjectMarshaler) error {
+	object, err := MarshalObject(value, enc.depth+1)
+	enc.fields[key] = object
+	return err
+}
+
+func (enc *mapFieldEncoder) Add(key string, value interface{}) {
+	if marshaler, ok := value.(ObjectMarshaler); ok {
+		object, err := MarshalObject(marshaler, enc.depth+1)
+		enc.fields[key] = object
+		if err != nil {
+			enc.fields[key+"_error"] = err.Error()
+		}
+		return
+	}
+	enc.fields[key] = value
+}
+
+// MarshalObject resolves marshaler into a map, it returns nil if marshaler is a nil pointer,
+// depth is the depth of the object, 1 for the field value of log.Entry.
+// A panic in the MarshalLogObject method is recovered and returned as the error.
+func MarshalObject(marshaler ObjectMarshaler, depth int) (object interface{}, err error) {
+	if v := reflect.ValueOf(marshaler); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, nil
+	}
+	if depth > maxObjectMarshalerDepth {
+		return nil, ErrObjectTooDeep
+	}
+	enc := &mapFieldEncoder{
+		fields: make(map[string]interface{}, 8),
+		depth:  depth,
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			object, err = enc.fields, PanicError(r)
+		}
+	}()
+	err = marshaler.MarshalLogObject(enc)
+	return enc.fields, err
+}
@@ -0,0 +1,76 @@
+package fields
+
+import (
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// FormatStacktrace returns the stack trace of the program counters returned by runtime.Callers,
+// one frame per line in the same form as the location of log.Entry, see FormatFrames.
+func FormatStacktrace(pcs []uintptr) string {
+	return FormatFrames(CallersFrames(pcs))
+}
+
+// CallersFrames returns the frames of the program counters returned by runtime.Callers.
+func CallersFrames(pcs []uintptr) []runtime.Frame {
+	if len(pcs) == 0 {
+		return nil
+	}
+	frames := make([]runtime.Frame, 0, len(pcs))
+	iter := runtime.CallersFrames(pcs)
+	for {
+		frame, more := iter.Next()
+		frames = append(frames, frame)
+		if !more {
+			return frames
+		}
+	}
+}
+
+// FormatFrames returns the stack trace of the frames, one frame per line in the form "function(file:line)",
+// the frames of runtime.main and runtime.goexit are omitted.
+func FormatFrames(frames []runtime.Frame) string {
+	var b strings.Builder
+	for _, frame := range frames {
+		switch frame.Function {
+		case "runtime.main", "runtime.goexit":
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		if frame.Function != "" {
+			b.WriteString(TrimFuncName(frame.Function))
+			b.WriteByte('(')
+			b.WriteString(TrimFileName(frame.File))
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(frame.Line))
+			b.WriteByte(')')
+		} else {
+			b.WriteString(TrimFileName(frame.File))
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(frame.Line))
+		}
+	}
+	return b.String()
+}
+
+// TrimFuncName trims the package path of the function name, for example "log.(*logger).Info".
+func TrimFuncName(name string) string {
+	return path.Base(name)
+}
+
+// TrimFileName trims the module cache and vendor directories of the file name.
+func TrimFileName(name string) string {
+	i := strings.Index(name, "/pkg/mod/") + len("/pkg/mod/")
+	if i >= len("/pkg/mod/") && i < len(name) /* BCE */ {
+		name = name[i:]
+	}
+	i = strings.LastIndex(name, "/vendor/") + len("/vendor/")
+	if i >= len("/vendor/") && i < len(name) /* BCE */ {
+		return name[i:]
+	}
+	return name
+}
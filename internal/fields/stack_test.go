@@ -0,0 +1,35 @@
+package fields
+
+import "testing"
+
+func TestTrimFileName(t *testing.T) {
+	tests := []struct {
+		str  string
+		want string
+	}{
+		{
+			"/a/b/c.go",
+			"/a/b/c.go",
+		},
+		{
+			"/a/pkg/mod/b/c.go",
+			"b/c.go",
+		},
+		{
+			"/a/pkg/mod/d/vendor/b/c.go",
+			"b/c.go",
+		},
+		{
+			"/a/vendor/b/c.go",
+			"b/c.go",
+		},
+	}
+
+	for _, v := range tests {
+		have := TrimFileName(v.str)
+		if have != v.want {
+			t.Errorf("have:%s, want:%s", have, v.want)
+			return
+		}
+	}
+}
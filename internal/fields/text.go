@@ -0,0 +1,173 @@
+package fields
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrorMarker is the prefix of the string which is written instead of the value which can not be encoded,
+// the string is "!ERROR: <reason>".
+const ErrorMarker = "!ERROR: "
+
+// PanicError returns the error which describes the recovered panic value r.
+func PanicError(r interface{}) error {
+	return fmt.Errorf("panic: %v", r)
+}
+
+var (
+	textRenderersMutex sync.Mutex
+	textRenderers      atomic.Value // map[reflect.Type]func(interface{}) string, copy on write
+)
+
+// RegisterTextRenderer registers the renderer for the values of typ, a nil renderer removes the registered one,
+// see log.RegisterTextRenderer.
+func RegisterTextRenderer(typ reflect.Type, renderer func(value interface{}) string) {
+	textRenderersMutex.Lock()
+	defer textRenderersMutex.Unlock()
+
+	old, _ := textRenderers.Load().(map[reflect.Type]func(interface{}) string)
+	m := make(map[reflect.Type]func(interface{}) string, len(old)+1)
+	for k, v := range old {
+		m[k] = v
+	}
+	if renderer == nil {
+		delete(m, typ)
+	} else {
+		m[typ] = renderer
+	}
+	textRenderers.Store(m)
+}
+
+func lookupTextRenderer(value interface{}) func(interface{}) string {
+	m, _ := textRenderers.Load().(map[reflect.Type]func(interface{}) string)
+	if len(m) == 0 {
+		return nil
+	}
+	return m[reflect.TypeOf(value)]
+}
+
+// TextEncoder returns the string form of the field values, the same as log.TextFormatter writes.
+type TextEncoder struct {
+	HexBytes   bool                     // []byte is encoded in lower case hexadecimal, or else in standard base64
+	FormatTime func(t time.Time) string // formats time.Time
+}
+
+// maxTextPointerDepth limits the pointers which are dereferenced, for example **int.
+const maxTextPointerDepth = 8
+
+// Text returns the string form of the field value, the value is rendered by
+//
+//  1. the renderer registered by RegisterTextRenderer
+//  2. string and json.RawMessage as is, []byte by HexBytes, time.Time by FormatTime
+//  3. "<nil>" for nil and nil pointer
+//  4. the Error, String, MarshalText or MarshalJSON method
+//  5. the value which the pointer points to
+//  6. fmt.Sprint
+func (e *TextEncoder) Text(value interface{}) string {
+	for depth := 0; ; depth++ {
+		if renderer := lookupTextRenderer(value); renderer != nil {
+			return renderer(value)
+		}
+		if str, ok := e.basicText(value); ok {
+			return str
+		}
+		rv := reflect.ValueOf(value)
+		isPtr := rv.Kind() == reflect.Ptr
+		if isPtr && rv.IsNil() {
+			return "<nil>"
+		}
+		if str, ok := MethodText(value); ok {
+			return str
+		}
+		if !isPtr || depth >= maxTextPointerDepth {
+			return fmt.Sprint(value)
+		}
+		value = rv.Elem().Interface()
+	}
+}
+
+func (e *TextEncoder) basicText(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "<nil>", true
+	case string:
+		return v, true
+	case json.RawMessage:
+		return string(v), true
+	case []byte:
+		if e.HexBytes {
+			return hex.EncodeToString(v), true
+		}
+		return base64.StdEncoding.EncodeToString(v), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int:
+		return strconv.FormatInt(int64(v), 10), true
+	case int8:
+		return strconv.FormatInt(int64(v), 10), true
+	case int16:
+		return strconv.FormatInt(int64(v), 10), true
+	case int32:
+		return strconv.FormatInt(int64(v), 10), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), true
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	case time.Time:
+		return e.FormatTime(v), true
+	case time.Duration:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
+
+// MethodText returns the string form of value by its method, if the method returns an error or panics,
+// the string "!ERROR: <reason>" is returned.
+func MethodText(value interface{}) (str string, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			str, ok = ErrorMarker+PanicError(r).Error(), true
+		}
+	}()
+	switch v := value.(type) {
+	case error:
+		return v.Error(), true
+	case fmt.Stringer:
+		return v.String(), true
+	case encoding.TextMarshaler:
+		data, err := v.MarshalText()
+		if err != nil {
+			return ErrorMarker + err.Error(), true
+		}
+		return string(data), true
+	case json.Marshaler:
+		data, err := v.MarshalJSON()
+		if err != nil {
+			return ErrorMarker + err.Error(), true
+		}
+		return string(data), true
+	default:
+		return "", false
+	}
+}
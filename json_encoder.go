@@ -0,0 +1,277 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	internalfields "github.com/chanxuehong/log/internal/fields"
+)
+
+// appendJSONKey writes the object key and the colon to b,
+// start is the length of b just after the opening brace of the object.
+func appendJSONKey(b *bytes.Buffer, key string, start int) {
+	if b.Len() > start {
+		b.WriteByte(',')
+	}
+	appendJSONString(b, key)
+	b.WriteByte(':')
+}
+
+// appendJSONValue writes the JSON encoding of value to b.
+//
+// The common types are encoded directly, the other types are encoded by encoding/json without HTML escaping.
+// If an error is returned, nothing is written to b.
+func appendJSONValue(b *bytes.Buffer, value interface{}) error {
+	var scratch [64]byte
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("null")
+	case string:
+		appendJSONString(b, v)
+	case bool:
+		b.Write(strconv.AppendBool(scratch[:0], v))
+	case int:
+		b.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int8:
+		b.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int16:
+		b.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int32:
+		b.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int64:
+		b.Write(strconv.AppendInt(scratch[:0], v, 10))
+	case uint:
+		b.Write(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case uint8:
+		b.Write(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case uint16:
+		b.Write(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case uint32:
+		b.Write(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case uint64:
+		b.Write(strconv.AppendUint(scratch[:0], v, 10))
+	case uintptr:
+		b.Write(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case float32:
+		return appendJSONFloat(b, float64(v), 32)
+	case float64:
+		return appendJSONFloat(b, v, 64)
+	case time.Time:
+		if y := v.Year(); y < 0 || y >= 10000 {
+			return appendJSONFallback(b, value)
+		}
+		b.WriteByte('"')
+		b.Write(v.AppendFormat(scratch[:0], time.RFC3339Nano))
+		b.WriteByte('"')
+	case time.Duration:
+		b.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case json.RawMessage:
+		if len(v) == 0 {
+			b.WriteString("null")
+			return nil
+		}
+		start := b.Len()
+		if err := json.Compact(b, v); err != nil {
+			b.Truncate(start)
+			return appendJSONFallback(b, value)
+		}
+	case error:
+		appendJSONString(b, v.Error())
+	case map[string]interface{}:
+		return appendJSONObject(b, v)
+	case map[interface{}]interface{}:
+		// encoding/json does not support it, it is decoded from YAML for example
+		m := make(map[string]interface{}, len(v))
+		for k, vv := range v {
+			m[textValue(k)] = vv
+		}
+		return appendJSONObject(b, m)
+	case []interface{}:
+		return appendJSONArray(b, v)
+	default:
+		return appendJSONFallback(b, value)
+	}
+	return nil
+}
+
+// jsonErrorMarker is the prefix of the string which replaces the field value that can not be encoded.
+const jsonErrorMarker = internalfields.ErrorMarker
+
+// appendJSONFieldValue writes the JSON encoding of the field value to b like appendJSONValue,
+// but if value can not be encoded or its method (for example MarshalJSON) panics, the string
+// "!ERROR: <reason>" is written instead, so that the other fields of the Entry are still written.
+func appendJSONFieldValue(b *bytes.Buffer, value interface{}) {
+	start := b.Len()
+	if err := safeAppendJSONValue(b, value); err != nil {
+		b.Truncate(start)
+		appendJSONString(b, jsonErrorMarker+err.Error())
+	}
+}
+
+func safeAppendJSONValue(b *bytes.Buffer, value interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = internalfields.PanicError(r)
+		}
+	}()
+	return appendJSONValue(b, value)
+}
+
+// appendJSONFieldObject writes the fields to b as a JSON object with sorted keys, see appendJSONFieldValue.
+func appendJSONFieldObject(b *bytes.Buffer, fields map[string]interface{}) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b.WriteByte('{')
+	start := b.Len()
+	for _, k := range keys {
+		appendJSONKey(b, k, start)
+		appendJSONFieldValue(b, fields[k])
+	}
+	b.WriteByte('}')
+}
+
+// appendJSONObject writes m to b as a JSON object with sorted keys.
+func appendJSONObject(b *bytes.Buffer, m map[string]interface{}) error {
+	if m == nil {
+		b.WriteString("null")
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	origin := b.Len()
+	b.WriteByte('{')
+	start := b.Len()
+	for _, k := range keys {
+		appendJSONKey(b, k, start)
+		if err := appendJSONValue(b, m[k]); err != nil {
+			b.Truncate(origin)
+			return err
+		}
+	}
+	b.WriteByte('}')
+	return nil
+}
+
+func appendJSONArray(b *bytes.Buffer, a []interface{}) error {
+	if a == nil {
+		b.WriteString("null")
+		return nil
+	}
+	origin := b.Len()
+	b.WriteByte('[')
+	for i, v := range a {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if err := appendJSONValue(b, v); err != nil {
+			b.Truncate(origin)
+			return err
+		}
+	}
+	b.WriteByte(']')
+	return nil
+}
+
+// appendJSONFloat writes f the same way as encoding/json.
+func appendJSONFloat(b *bytes.Buffer, f float64, bits int) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return &json.UnsupportedValueError{Str: strconv.FormatFloat(f, 'g', -1, bits)}
+	}
+	var scratch [64]byte
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	data := strconv.AppendFloat(scratch[:0], f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(data)
+		if n >= 4 && data[n-4] == 'e' && data[n-3] == '-' && data[n-2] == '0' {
+			data[n-2] = data[n-1]
+			data = data[:n-1]
+		}
+	}
+	b.Write(data)
+	return nil
+}
+
+func appendJSONFallback(b *bytes.Buffer, value interface{}) error {
+	encoder := json.NewEncoder(b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	// remove the trailing newline
+	b.Truncate(b.Len() - 1)
+	return nil
+}
+
+// appendJSONString writes s to b as a JSON string, unlike encoding/json the HTML characters are not escaped.
+func appendJSONString(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= ' ' && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			b.WriteString(s[start:i])
+			switch c {
+			case '"', '\\':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case '\n':
+				b.WriteString(`\n`)
+			case '\r':
+				b.WriteString(`\r`)
+			case '\t':
+				b.WriteString(`\t`)
+			default:
+				b.WriteString(`\u00`)
+				b.WriteByte(hexDigits[c>>4])
+				b.WriteByte(hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteString(s[start:i])
+			b.WriteString(`\ufffd`)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 is LINE SEPARATOR, U+2029 is PARAGRAPH SEPARATOR,
+		// they are escaped for the sake of JSONP, the same as encoding/json.
+		if r == '\u2028' || r == '\u2029' {
+			b.WriteString(s[start:i])
+			b.WriteString(`\u202`)
+			b.WriteByte(hexDigits[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b.WriteString(s[start:])
+	b.WriteByte('"')
+}
@@ -0,0 +1,125 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAppendJSONValue(t *testing.T) {
+	tests := []interface{}{
+		nil,
+		"",
+		"abc",
+		"中文",
+		"a\"b\\c\n\r\t\x00\x1f",
+		"\u2028\u2029",
+		true,
+		false,
+		int(-1),
+		int8(-8),
+		int16(-16),
+		int32(-32),
+		int64(math.MinInt64),
+		uint(1),
+		uint8(8),
+		uint16(16),
+		uint32(32),
+		uint64(math.MaxUint64),
+		uintptr(64),
+		float32(3.14),
+		float32(1e-7),
+		float64(0),
+		float64(3.1415926),
+		float64(1e21),
+		float64(-1e-7),
+		time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+		time.Date(2018, time.May, 20, 8, 20, 30, 0, time.FixedZone("", 8*60*60)),
+		time.Second + 500*time.Millisecond,
+		json.RawMessage(`{ "a" : [1, 2] }`),
+		map[string]interface{}{"b": 1, "a": []interface{}{"x", 2.5, nil}},
+		[]interface{}{},
+		[]int{1, 2, 3},
+		struct {
+			Name string `json:"name"`
+			Age  int    `json:"age"`
+		}{"Alice", 30},
+	}
+	for _, v := range tests {
+		var buf bytes.Buffer
+		if err := appendJSONValue(&buf, v); err != nil {
+			t.Errorf("value:%#v, error:%v", v, err)
+			return
+		}
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if raw, ok := v.(json.RawMessage); ok {
+			var compacted bytes.Buffer
+			json.Compact(&compacted, raw)
+			want = compacted.Bytes()
+		}
+		if have := buf.String(); have != string(want) {
+			t.Errorf("value:%#v, have:%s, want:%s", v, have, want)
+			return
+		}
+	}
+}
+
+func TestAppendJSONValue_Special(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{
+			"<a>&</a>",
+			`"<a>&</a>"`,
+		},
+		{
+			"a\xffb",
+			`"a\ufffdb"`,
+		},
+		{
+			errors.New("error <1>"),
+			`"error <1>"`,
+		},
+		{
+			json.RawMessage(nil),
+			`null`,
+		},
+		{
+			map[interface{}]interface{}{"b": 1, 2: map[interface{}]interface{}{"c": true}},
+			`{"2":{"c":true},"b":1}`,
+		},
+	}
+	for _, v := range tests {
+		var buf bytes.Buffer
+		if err := appendJSONValue(&buf, v.value); err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if have := buf.String(); have != v.want {
+			t.Errorf("have:%s, want:%s", have, v.want)
+			return
+		}
+	}
+
+	// unsupported value
+	for _, v := range []interface{}{math.NaN(), math.Inf(1), make(chan int), []interface{}{1, math.NaN()}} {
+		var buf bytes.Buffer
+		buf.WriteString("prefix")
+		if err := appendJSONValue(&buf, v); err == nil {
+			t.Errorf("value:%#v, want error", v)
+			return
+		}
+		if have := buf.String(); have != "prefix" {
+			t.Errorf("have:%s, want:%s", have, "prefix")
+			return
+		}
+	}
+}
@@ -2,33 +2,74 @@ package log
 
 import (
 	"bytes"
-	"encoding/json"
+	"sort"
 )
 
-var JsonFormatter Formatter = jsonFormatter{}
+// JsonFormatter formats the Entry as a JSON object, the standard fields are written first
+// in the order time, level, request_id, location and msg, then the fields sorted by key.
+// The field value which can not be encoded is written as the string "!ERROR: <reason>".
+var JsonFormatter Formatter = NewJsonFormatter()
 
-type jsonFormatter struct{}
+// NewJsonFormatter returns a Formatter which formats the Entry as a JSON object.
+func NewJsonFormatter(opts ...FormatterOption) Formatter {
+	return jsonFormatter{opts: newFormatterOptions(opts)}
+}
+
+type jsonFormatter struct {
+	opts *formatterOptions
+}
 
-func (jsonFormatter) Format(entry *Entry) ([]byte, error) {
+func (f jsonFormatter) Format(entry *Entry) ([]byte, error) {
 	var buffer *bytes.Buffer
 	if entry.Buffer != nil {
 		buffer = entry.Buffer
 	} else {
 		buffer = bytes.NewBuffer(make([]byte, 0, 16<<10))
 	}
-	var fields map[string]interface{}
-	if fields = entry.Fields; len(fields) > 0 {
-		fixFieldsConflictAndHandleErrorFields(fields)
-	} else {
-		fields = make(map[string]interface{}, 8)
+	opts := f.opts
+	fields := entry.Fields
+	if len(fields) > 0 {
+		fixFieldsConflictAndHandleErrorFields(fields, opts.stdKeys)
+	}
+
+	buffer.WriteByte('{')
+	start := buffer.Len()
+	if opts.hasTime() {
+		appendJSONKey(buffer, opts.keys.Time, start)
+		if opts.isNumericTime() {
+			var scratch [32]byte
+			buffer.Write(opts.appendUnixTime(scratch[:0], entry.Time))
+		} else {
+			appendJSONString(buffer, opts.formatTime(entry.Time))
+		}
+	}
+	if opts.hasLevel() {
+		appendJSONKey(buffer, opts.keys.Level, start)
+		appendJSONString(buffer, entry.Level.String())
+	}
+	if opts.hasTraceId(entry.TraceId) {
+		appendJSONKey(buffer, opts.keys.TraceId, start)
+		appendJSONString(buffer, entry.TraceId)
+	}
+	if opts.hasLocation() {
+		appendJSONKey(buffer, opts.keys.Location, start)
+		appendJSONString(buffer, entry.Location)
+	}
+	if opts.hasMessage() {
+		appendJSONKey(buffer, opts.keys.Message, start)
+		appendJSONString(buffer, entry.Message)
 	}
-	fields[fieldKeyTime] = FormatTime(entry.Time.In(_beijingLocation))
-	fields[fieldKeyLevel] = entry.Level.String()
-	fields[fieldKeyTraceId] = entry.TraceId
-	fields[fieldKeyLocation] = entry.Location
-	fields[fieldKeyMessage] = entry.Message
-	if err := json.NewEncoder(buffer).Encode(fields); err != nil {
-		return nil, err
+	if len(fields) > 0 {
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			appendJSONKey(buffer, k, start)
+			appendJSONFieldValue(buffer, fields[k])
+		}
 	}
+	buffer.WriteString("}\n")
 	return buffer.Bytes(), nil
 }
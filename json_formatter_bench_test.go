@@ -0,0 +1,73 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// legacyJsonFormat is the map based implementation of JsonFormatter, which is kept for comparison.
+func legacyJsonFormat(entry *Entry) ([]byte, error) {
+	buffer := entry.Buffer
+	fields := entry.Fields
+	if len(fields) > 0 {
+		fixFieldsConflictAndHandleErrorFields(fields, stdFieldKeys)
+	} else {
+		fields = make(map[string]interface{}, 8)
+	}
+	fields[fieldKeyTime] = FormatTime(entry.Time.In(_beijingLocation))
+	fields[fieldKeyLevel] = entry.Level.String()
+	fields[fieldKeyTraceId] = entry.TraceId
+	fields[fieldKeyLocation] = entry.Location
+	fields[fieldKeyMessage] = entry.Message
+	if err := json.NewEncoder(buffer).Encode(fields); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func newBenchmarkEntry(buffer *bytes.Buffer) *Entry {
+	return &Entry{
+		Location: "log.BenchmarkJsonFormatter(github.com/chanxuehong/log/json_formatter_bench_test.go:40)",
+		Time:     time.Now(),
+		Level:    InfoLevel,
+		TraceId:  "39eff2b97d0311e89fcd000c294d93c4",
+		Message:  "benchmark message",
+		Fields: map[string]interface{}{
+			"string":   "value",
+			"int":      123456,
+			"float":    3.1415926,
+			"bool":     true,
+			"time":     time.Now(),
+			"duration": time.Second,
+			"error":    testError{},
+			"raw":      json.RawMessage(`{"code":0,"msg":""}`),
+		},
+		Buffer: buffer,
+	}
+}
+
+func BenchmarkJsonFormatter(b *testing.B) {
+	var buffer bytes.Buffer
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buffer.Reset()
+		if _, err := JsonFormatter.Format(newBenchmarkEntry(&buffer)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLegacyJsonFormatter(b *testing.B) {
+	var buffer bytes.Buffer
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buffer.Reset()
+		if _, err := legacyJsonFormat(newBenchmarkEntry(&buffer)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
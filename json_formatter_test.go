@@ -2,6 +2,8 @@ package log
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"reflect"
 	"testing"
 	"time"
@@ -69,3 +71,72 @@ func TestJsonFormatter_Format(t *testing.T) {
 		return
 	}
 }
+
+func TestJsonFormatter_FormatOrder(t *testing.T) {
+	entry := &Entry{
+		Location: "function(file:line)",
+		Time:     time.Date(2018, time.May, 20, 8, 20, 30, 666000000, time.UTC),
+		Level:    InfoLevel,
+		TraceId:  "trace_id_123456789",
+		Message:  "<message>",
+		Fields: map[string]interface{}{
+			"b":   2,
+			"a":   "1",
+			"c":   time.Second,
+			"msg": "msg",
+		},
+		Buffer: nil,
+	}
+	have, err := JsonFormatter.Format(entry)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	want := `{"time":"2018-05-20 16:20:30.666","level":"info","request_id":"trace_id_123456789","location":"function(file:line)",` +
+		`"msg":"<message>","a":"1","b":2,"c":1000000000,"field.msg":"msg"}` + "\n"
+	if string(have) != want {
+		t.Errorf("\nhave:%s\nwant:%s", have, want)
+		return
+	}
+}
+
+type testPanicMarshaler struct{}
+
+func (testPanicMarshaler) MarshalJSON() ([]byte, error) { panic("marshal_panic_123456789") }
+
+type testErrorMarshaler struct{}
+
+func (testErrorMarshaler) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("marshal_error_123456789")
+}
+
+func TestJsonFormatter_FormatBadValue(t *testing.T) {
+	entry := &Entry{
+		Time:    time.Date(2018, time.May, 20, 8, 20, 30, 666000000, time.UTC),
+		Level:   InfoLevel,
+		Message: "msg",
+		Fields: map[string]interface{}{
+			"a": math.NaN(),
+			"b": make(chan int),
+			"c": testPanicMarshaler{},
+			"d": testErrorMarshaler{},
+			"e": "ok",
+		},
+	}
+	formatter := NewJsonFormatter(WithFieldKeys(FieldKeys{TraceId: OmitFieldKey, Location: OmitFieldKey}))
+	have, err := formatter.Format(entry)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	want := `{"time":"2018-05-20 16:20:30.666","level":"info","msg":"msg",` +
+		`"a":"!ERROR: json: unsupported value: NaN",` +
+		`"b":"!ERROR: json: unsupported type: chan int",` +
+		`"c":"!ERROR: panic: marshal_panic_123456789",` +
+		`"d":"!ERROR: json: error calling MarshalJSON for type *log.testErrorMarshaler: marshal_error_123456789",` +
+		`"e":"ok"}` + "\n"
+	if string(have) != want {
+		t.Errorf("\nhave:%s\nwant:%s", have, want)
+		return
+	}
+}
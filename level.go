@@ -1,8 +1,17 @@
 package log
 
 import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 func init() {
@@ -19,13 +28,22 @@ const (
 	WarnLevel
 	InfoLevel
 	DebugLevel
+	TraceLevel // more verbose than DebugLevel, for example to log the details of every request
 )
 
 func isValidLevel(level Level) bool {
-	return level >= FatalLevel && level <= DebugLevel
+	if level >= FatalLevel && level <= TraceLevel {
+		return true
+	}
+	_, ok := lookupLevel(level)
+	return ok
 }
 func isLevelEnabled(level, loggerLevel Level) bool {
-	return loggerLevel >= level
+	if level <= TraceLevel && loggerLevel <= TraceLevel {
+		return loggerLevel >= level
+	}
+	rank := level.Rank()
+	return rank > 0 && loggerLevel.Rank() >= rank
 }
 
 const (
@@ -34,10 +52,26 @@ const (
 	WarnLevelString  = "warning"
 	InfoLevelString  = "info"
 	DebugLevelString = "debug"
+	TraceLevelString = "trace"
 )
 
+// levelAliases are the other names accepted when the level string is parsed, in addition to the names of
+// the levels (see Level.String) and the numeric values of the levels:
+//
+//	"warn"        WarnLevel
+//	"err"         ErrorLevel
+//	"information" InfoLevel
+var levelAliases = map[string]Level{
+	"warn":        WarnLevel,
+	"err":         ErrorLevel,
+	"information": InfoLevel,
+}
+
+// parseLevelString parses the level string, it is case-insensitive, and accepts the aliases (see levelAliases)
+// and the numeric values of the valid levels, for example "warning", "WARN" and "3" are all WarnLevel.
 func parseLevelString(str string) (level Level, ok bool) {
-	switch strings.ToLower(str) {
+	str = strings.ToLower(strings.TrimSpace(str))
+	switch str {
 	case DebugLevelString:
 		return DebugLevel, true
 	case InfoLevelString:
@@ -48,9 +82,34 @@ func parseLevelString(str string) (level Level, ok bool) {
 		return ErrorLevel, true
 	case FatalLevelString:
 		return FatalLevel, true
-	default:
+	case TraceLevelString:
+		return TraceLevel, true
+	}
+	if level, ok = levelAliases[str]; ok {
+		return level, true
+	}
+	if n, err := strconv.ParseUint(str, 10, 0); err == nil {
+		if level = Level(n); isValidLevel(level) {
+			return level, true
+		}
 		return invalidLevel, false
 	}
+	if registry := getLevelRegistry(); registry != nil {
+		level, ok = registry.names[str]
+		return level, ok
+	}
+	return invalidLevel, false
+}
+
+// ParseLevel parses the level string, it is case-insensitive, and accepts the names of the levels
+// (including the ones registered by RegisterLevel), the aliases "warn", "err" and "information",
+// and the numeric values of the levels, for example "warning", "WARN" and "3" are all WarnLevel.
+func ParseLevel(str string) (Level, error) {
+	level, ok := parseLevelString(str)
+	if !ok {
+		return invalidLevel, fmt.Errorf("log: invalid level %q", str)
+	}
+	return level, nil
 }
 
 type Level uint
@@ -67,7 +126,328 @@ func (level Level) String() string {
 		return InfoLevelString
 	case DebugLevel:
 		return DebugLevelString
+	case TraceLevel:
+		return TraceLevelString
 	default:
+		if def, ok := lookupLevel(level); ok {
+			return def.Name
+		}
 		return fmt.Sprintf("unknown_%d", level)
 	}
 }
+
+var (
+	_ encoding.TextMarshaler   = Level(0)
+	_ encoding.TextUnmarshaler = (*Level)(nil)
+	_ json.Marshaler           = Level(0)
+	_ json.Unmarshaler         = (*Level)(nil)
+	_ flag.Value               = (*Level)(nil)
+)
+
+// MarshalText implements encoding.TextMarshaler, it returns the name of the level (see Level.String),
+// or the number of the level if it is invalid (for example the zero Level). UnmarshalText decodes only "0" back,
+// the other invalid numbers are rejected.
+func (level Level) MarshalText() ([]byte, error) {
+	if !isValidLevel(level) {
+		return strconv.AppendUint(nil, uint64(level), 10), nil
+	}
+	return []byte(level.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, text is parsed the same as ParseLevel,
+// so the level can be loaded from the configs (for example JSON, YAML and TOML) and the environment variables.
+// Besides, "0" written by MarshalText for the zero Level is decoded back to the zero Level.
+func (level *Level) UnmarshalText(text []byte) error {
+	v, err := ParseLevel(string(text))
+	if err != nil {
+		if strings.TrimSpace(string(text)) != "0" {
+			return err
+		}
+		v = invalidLevel
+	}
+	*level = v
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, the level is encoded as the JSON string of its name,
+// or the JSON number of the level if it is invalid, see MarshalText.
+func (level Level) MarshalJSON() ([]byte, error) {
+	if !isValidLevel(level) {
+		return strconv.AppendUint(nil, uint64(level), 10), nil
+	}
+	return json.Marshal(level.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, data can be either the JSON string or the JSON number of the level,
+// both are parsed the same as UnmarshalText.
+func (level *Level) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" { // the same as encoding/json, null is a no-op
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		return level.UnmarshalText([]byte(str))
+	}
+	return level.UnmarshalText(data)
+}
+
+// Set implements flag.Value, str is parsed the same as ParseLevel, see LevelFlag.
+func (level *Level) Set(str string) error {
+	v, err := ParseLevel(str)
+	if err != nil {
+		return err
+	}
+	*level = v
+	return nil
+}
+
+// LevelFlag defines the level flag with the specified name and default value in fs
+// (flag.CommandLine if fs is nil), and returns the address of the level which stores the value of the flag,
+// for example
+//
+//	level := log.LevelFlag(nil, "log-level", log.InfoLevel)
+//	flag.Parse()
+//	lg := log.New(log.WithLevel(*level))
+//
+// The flag accepts the same strings as ParseLevel, its usage lists the names of the levels registered
+// before LevelFlag is called and the aliases.
+func LevelFlag(fs *flag.FlagSet, name string, value Level) *Level {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	level := new(Level)
+	*level = value
+	fs.Var(level, name, levelFlagUsage())
+	return level
+}
+
+// levelFlagUsage returns the usage of the level flag, see LevelFlag.
+func levelFlagUsage() string {
+	names := []string{FatalLevelString, ErrorLevelString, WarnLevelString, InfoLevelString, DebugLevelString, TraceLevelString}
+	if registry := getLevelRegistry(); registry != nil {
+		for level := firstCustomLevel; level < registry.next; level++ {
+			names = append(names, registry.levels[level].Name)
+		}
+	}
+	aliases := make([]string, 0, len(levelAliases))
+	for alias := range levelAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return "log level, one of " + strings.Join(names, ", ") + " (or the aliases " + strings.Join(aliases, ", ") + ")"
+}
+
+// Rank returns the severity order of the level, the smaller rank is more severe.
+// The ranks of the built-in levels are 100 (FatalLevel), 200, 300, 400, 500 and 600 (TraceLevel),
+// the rank of the unknown level is 0.
+func (level Level) Rank() int {
+	if level >= FatalLevel && level <= TraceLevel {
+		return int(level) * 100
+	}
+	if def, ok := lookupLevel(level); ok {
+		return def.Rank
+	}
+	return 0
+}
+
+// builtin returns the built-in level whose rank is the nearest to the rank of level,
+// the more severe one is returned if there are two, and invalidLevel is returned for the unknown level.
+func (level Level) builtin() Level {
+	if level >= FatalLevel && level <= TraceLevel {
+		return level
+	}
+	rank := level.Rank()
+	if rank <= 0 {
+		return invalidLevel
+	}
+	builtin := Level((rank + 49) / 100)
+	if builtin < FatalLevel {
+		return FatalLevel
+	}
+	if builtin > TraceLevel {
+		return TraceLevel
+	}
+	return builtin
+}
+
+// SyslogSeverity returns the syslog severity (RFC 5424) of the level,
+// FatalLevel is mapped to critical (2), ErrorLevel to error (3), WarnLevel to warning (4),
+// InfoLevel to informational (6), DebugLevel, TraceLevel and the unknown levels to debug (7).
+// The custom level is mapped to LevelDefinition.SyslogSeverity, or the severity of the nearest built-in level.
+func (level Level) SyslogSeverity() int {
+	if def, ok := lookupLevel(level); ok && def.SyslogSeverity != nil {
+		return *def.SyslogSeverity
+	}
+	switch level.builtin() {
+	case FatalLevel:
+		return 2
+	case ErrorLevel:
+		return 3
+	case WarnLevel:
+		return 4
+	case InfoLevel:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// OtelSeverityNumber returns the OpenTelemetry SeverityNumber of the level,
+// FatalLevel is mapped to FATAL (21), ErrorLevel to ERROR (17), WarnLevel to WARN (13),
+// InfoLevel to INFO (9), DebugLevel to DEBUG (5), TraceLevel to TRACE (1), and the unknown levels to UNSPECIFIED (0).
+// The custom level is mapped to LevelDefinition.OtelSeverityNumber, or the number of the nearest built-in level.
+func (level Level) OtelSeverityNumber() int {
+	if def, ok := lookupLevel(level); ok && def.OtelSeverityNumber > 0 {
+		return def.OtelSeverityNumber
+	}
+	switch level.builtin() {
+	case FatalLevel:
+		return 21
+	case ErrorLevel:
+		return 17
+	case WarnLevel:
+		return 13
+	case InfoLevel:
+		return 9
+	case DebugLevel:
+		return 5
+	case TraceLevel:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// LevelDefinition defines the custom level, see RegisterLevel.
+type LevelDefinition struct {
+	// Name is the string form of the level, it is case-insensitive when parsed and must not be used by other levels.
+	// The leading and trailing spaces are trimmed, and the name must not be a number.
+	Name string
+
+	// Rank is the severity order of the level, the smaller rank is more severe, it must be positive.
+	// For example, the rank of "notice" can be 350 which is between WarnLevel (300) and InfoLevel (400), see Level.Rank.
+	Rank int
+
+	// SyslogSeverity points to the syslog severity (0 to 7) of the level, nil means the severity of the nearest
+	// built-in level (by rank) is used, see Level.SyslogSeverity.
+	SyslogSeverity *int
+
+	// OtelSeverityNumber is the OpenTelemetry SeverityNumber (1 to 24) of the level, 0 means the number
+	// of the nearest built-in level (by rank) is used, see Level.OtelSeverityNumber.
+	OtelSeverityNumber int
+}
+
+// firstCustomLevel is the first level returned by RegisterLevel, the gap from TraceLevel is reserved for
+// the built-in levels.
+const firstCustomLevel Level = 1000
+
+type levelRegistry struct {
+	levels map[Level]LevelDefinition
+	names  map[string]Level // the lower case names
+	next   Level
+}
+
+var (
+	levelRegistryMutex sync.Mutex
+	levelRegistryValue atomic.Value // *levelRegistry, copy on write
+)
+
+func getLevelRegistry() *levelRegistry {
+	registry, _ := levelRegistryValue.Load().(*levelRegistry)
+	return registry
+}
+
+func lookupLevel(level Level) (def LevelDefinition, ok bool) {
+	if level <= TraceLevel {
+		return
+	}
+	registry := getLevelRegistry()
+	if registry == nil {
+		return
+	}
+	def, ok = registry.levels[level]
+	return
+}
+
+// RegisterLevel registers the custom level and returns it, the level is recognized by all the Loggers and formatters,
+// for example
+//
+//	var noticeSeverity = 5 // the syslog severity of notice
+//	var NoticeLevel = log.MustRegisterLevel(log.LevelDefinition{Name: "notice", Rank: 350, SyslogSeverity: &noticeSeverity})
+//
+// It is normally called in the package initialization. The custom levels are numbered in the order of registration,
+// so the number of a custom level differs between programs, the level should be stored and exchanged by its name
+// (see Level.MarshalText), as CborFormatter and MsgpackFormatter do.
+func RegisterLevel(def LevelDefinition) (Level, error) {
+	def.Name = strings.TrimSpace(def.Name)
+	name := strings.ToLower(def.Name)
+	if name == "" {
+		return invalidLevel, errors.New("log: the name of level must not be empty")
+	}
+	if isDigits(name) {
+		return invalidLevel, fmt.Errorf("log: the name of level must not be a number: %q", def.Name)
+	}
+	if def.Rank <= 0 {
+		return invalidLevel, fmt.Errorf("log: the rank of level %q must be positive", def.Name)
+	}
+	if severity := def.SyslogSeverity; severity != nil {
+		if *severity < 0 || *severity > 7 {
+			return invalidLevel, fmt.Errorf("log: invalid syslog severity of level %q: %d", def.Name, *severity)
+		}
+		severityCopy := *severity // the caller may change *def.SyslogSeverity later
+		def.SyslogSeverity = &severityCopy
+	}
+	if def.OtelSeverityNumber < 0 || def.OtelSeverityNumber > 24 {
+		return invalidLevel, fmt.Errorf("log: invalid OpenTelemetry severity number of level %q: %d", def.Name, def.OtelSeverityNumber)
+	}
+
+	levelRegistryMutex.Lock()
+	defer levelRegistryMutex.Unlock()
+
+	if _, ok := parseLevelString(name); ok {
+		return invalidLevel, fmt.Errorf("log: level %q is already registered", def.Name)
+	}
+	registry := &levelRegistry{
+		levels: make(map[Level]LevelDefinition),
+		names:  make(map[string]Level),
+		next:   firstCustomLevel,
+	}
+	if old := getLevelRegistry(); old != nil {
+		for k, v := range old.levels {
+			registry.levels[k] = v
+		}
+		for k, v := range old.names {
+			registry.names[k] = v
+		}
+		registry.next = old.next
+	}
+	level := registry.next
+	registry.next++
+	registry.levels[level] = def
+	registry.names[name] = level
+	levelRegistryValue.Store(registry)
+	return level, nil
+}
+
+// isDigits reports whether str consists of the decimal digits only, such a name would be parsed as the number of level.
+func isDigits(str string) bool {
+	for i := 0; i < len(str); i++ {
+		if str[i] < '0' || str[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MustRegisterLevel is like RegisterLevel but panics if the level can not be registered.
+func MustRegisterLevel(def LevelDefinition) Level {
+	level, err := RegisterLevel(def)
+	if err != nil {
+		panic(err)
+	}
+	return level
+}
@@ -1,6 +1,11 @@
 package log
 
 import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -29,6 +34,10 @@ func TestIsValidLevel(t *testing.T) {
 			DebugLevel,
 			true,
 		},
+		{
+			TraceLevel,
+			true,
+		},
 		{
 			invalidLevel,
 			false,
@@ -42,7 +51,7 @@ func TestIsValidLevel(t *testing.T) {
 			false,
 		},
 		{
-			6,
+			7,
 			false,
 		},
 	}
@@ -195,6 +204,30 @@ func TestIsLevelEnabled(t *testing.T) {
 			DebugLevel,
 			true,
 		},
+		{
+			DebugLevel,
+			TraceLevel,
+			true,
+		},
+
+		// level is trace
+		{
+			TraceLevel,
+			DebugLevel,
+			false,
+		},
+		{
+			TraceLevel,
+			TraceLevel,
+			true,
+		},
+
+		// logger level is invalid
+		{
+			FatalLevel,
+			invalidLevel,
+			false,
+		},
 	}
 	for _, v := range tests {
 		have := isLevelEnabled(v.level, v.loggerLevel)
@@ -238,6 +271,16 @@ func TestParseLevelString(t *testing.T) {
 		},
 		{
 			"trace",
+			TraceLevel,
+			true,
+		},
+		{
+			"DEBUG",
+			DebugLevel,
+			true,
+		},
+		{
+			"verbose",
 			invalidLevel,
 			false,
 		},
@@ -246,6 +289,46 @@ func TestParseLevelString(t *testing.T) {
 			invalidLevel,
 			false,
 		},
+		{
+			"WARN",
+			WarnLevel,
+			true,
+		},
+		{
+			" err ",
+			ErrorLevel,
+			true,
+		},
+		{
+			"information",
+			InfoLevel,
+			true,
+		},
+		{
+			"3",
+			WarnLevel,
+			true,
+		},
+		{
+			"6",
+			TraceLevel,
+			true,
+		},
+		{
+			"0",
+			invalidLevel,
+			false,
+		},
+		{
+			"7",
+			invalidLevel,
+			false,
+		},
+		{
+			"-1",
+			invalidLevel,
+			false,
+		},
 		{
 			"",
 			invalidLevel,
@@ -286,6 +369,14 @@ func TestLevel_String(t *testing.T) {
 			DebugLevel,
 			"debug",
 		},
+		{
+			TraceLevel,
+			"trace",
+		},
+		{
+			100,
+			"unknown_100",
+		},
 	}
 	for _, v := range tests {
 		str := v.level.String()
@@ -295,3 +386,390 @@ func TestLevel_String(t *testing.T) {
 		}
 	}
 }
+
+func TestLevel_SyslogSeverity(t *testing.T) {
+	tests := []struct {
+		level    Level
+		severity int
+	}{
+		{
+			FatalLevel,
+			2,
+		},
+		{
+			ErrorLevel,
+			3,
+		},
+		{
+			WarnLevel,
+			4,
+		},
+		{
+			InfoLevel,
+			6,
+		},
+		{
+			DebugLevel,
+			7,
+		},
+		{
+			TraceLevel,
+			7,
+		},
+		{
+			100,
+			7,
+		},
+	}
+	for _, v := range tests {
+		severity := v.level.SyslogSeverity()
+		if severity != v.severity {
+			t.Errorf("level:%v, have:%d, want:%d", v.level, severity, v.severity)
+			return
+		}
+	}
+}
+
+func TestLevel_OtelSeverityNumber(t *testing.T) {
+	tests := []struct {
+		level    Level
+		severity int
+	}{
+		{
+			FatalLevel,
+			21,
+		},
+		{
+			ErrorLevel,
+			17,
+		},
+		{
+			WarnLevel,
+			13,
+		},
+		{
+			InfoLevel,
+			9,
+		},
+		{
+			DebugLevel,
+			5,
+		},
+		{
+			TraceLevel,
+			1,
+		},
+		{
+			100,
+			0,
+		},
+	}
+	for _, v := range tests {
+		severity := v.level.OtelSeverityNumber()
+		if severity != v.severity {
+			t.Errorf("level:%v, have:%d, want:%d", v.level, severity, v.severity)
+			return
+		}
+	}
+}
+
+// resetLevelRegistry removes the custom levels registered by the test.
+func resetLevelRegistry() {
+	levelRegistryValue.Store((*levelRegistry)(nil))
+}
+
+func TestRegisterLevel(t *testing.T) {
+	defer resetLevelRegistry()
+
+	noticeSeverity, emergencySeverity := 5, 0
+	notice, err := RegisterLevel(LevelDefinition{Name: "Notice", Rank: 350, SyslogSeverity: &noticeSeverity})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	noticeSeverity = 6 // the registered severity is not changed
+	critical := MustRegisterLevel(LevelDefinition{Name: "critical", Rank: 150, OtelSeverityNumber: 22})
+	emergency := MustRegisterLevel(LevelDefinition{Name: "emergency", Rank: 50, SyslogSeverity: &emergencySeverity})
+	if notice != firstCustomLevel || critical != firstCustomLevel+1 || emergency != firstCustomLevel+2 {
+		t.Errorf("have:(%d, %d, %d), want:(%d, %d, %d)", notice, critical, emergency,
+			firstCustomLevel, firstCustomLevel+1, firstCustomLevel+2)
+		return
+	}
+
+	tests := []struct {
+		level   Level
+		str     string
+		rank    int
+		syslog  int
+		otel    int
+		gcp     string
+		enabled bool // enabled by the InfoLevel logger
+		builtin Level
+	}{
+		{notice, "Notice", 350, 5, 13, "NOTICE", true, WarnLevel},
+		{critical, "critical", 150, 2, 22, "CRITICAL", true, FatalLevel},
+		{emergency, "emergency", 50, 0, 21, "EMERGENCY", true, FatalLevel},
+		{InfoLevel, "info", 400, 6, 9, "INFO", true, InfoLevel},
+		{TraceLevel, "trace", 600, 7, 1, "DEBUG", false, TraceLevel},
+	}
+	for _, v := range tests {
+		if !isValidLevel(v.level) {
+			t.Errorf("level:%d, want valid", v.level)
+			return
+		}
+		if have := v.level.String(); have != v.str {
+			t.Errorf("have:%s, want:%s", have, v.str)
+			return
+		}
+		if have := v.level.Rank(); have != v.rank {
+			t.Errorf("level:%s, have:%d, want:%d", v.level, have, v.rank)
+			return
+		}
+		if have := v.level.SyslogSeverity(); have != v.syslog {
+			t.Errorf("level:%s, have:%d, want:%d", v.level, have, v.syslog)
+			return
+		}
+		if have := v.level.OtelSeverityNumber(); have != v.otel {
+			t.Errorf("level:%s, have:%d, want:%d", v.level, have, v.otel)
+			return
+		}
+		if have := gcpSeverity(v.level); have != v.gcp {
+			t.Errorf("level:%s, have:%s, want:%s", v.level, have, v.gcp)
+			return
+		}
+		if have := isLevelEnabled(v.level, InfoLevel); have != v.enabled {
+			t.Errorf("level:%s, have:%t, want:%t", v.level, have, v.enabled)
+			return
+		}
+		if have := v.level.builtin(); have != v.builtin {
+			t.Errorf("level:%s, have:%s, want:%s", v.level, have, v.builtin)
+			return
+		}
+		if have, ok := parseLevelString(strings.ToUpper(v.str)); !ok || have != v.level {
+			t.Errorf("str:%s, have:(%d, %t), want:(%d, true)", v.str, have, ok, v.level)
+			return
+		}
+	}
+
+	// the logger at the custom level
+	if !isLevelEnabled(ErrorLevel, notice) || !isLevelEnabled(notice, notice) || isLevelEnabled(InfoLevel, notice) {
+		t.Error("not expected isLevelEnabled for the notice logger")
+		return
+	}
+	if isValidLevel(firstCustomLevel + 3) {
+		t.Errorf("level:%d, want invalid", firstCustomLevel+3)
+		return
+	}
+
+	// invalid definitions
+	tooLargeSeverity, negativeSeverity := 8, -1
+	for _, def := range []LevelDefinition{
+		{Name: "", Rank: 100},
+		{Name: "  ", Rank: 100},
+		{Name: "42", Rank: 100},
+		{Name: " 007 ", Rank: 100},
+		{Name: "zero", Rank: 0},
+		{Name: "syslog", Rank: 100, SyslogSeverity: &tooLargeSeverity},
+		{Name: "syslog", Rank: 100, SyslogSeverity: &negativeSeverity},
+		{Name: "otel", Rank: 100, OtelSeverityNumber: 25},
+		{Name: "WARNING", Rank: 100},
+		{Name: "notice", Rank: 100},
+	} {
+		if _, err := RegisterLevel(def); err == nil {
+			t.Errorf("definition:%+v, want error", def)
+			return
+		}
+	}
+
+	// the name is trimmed
+	alert := MustRegisterLevel(LevelDefinition{Name: " Alert\t", Rank: 80})
+	if have, want := alert.String(), "Alert"; have != want {
+		t.Errorf("have:%s, want:%s", have, want)
+		return
+	}
+	if level, err := ParseLevel("alert"); err != nil || level != alert {
+		t.Errorf("have:(%d, %v), want:(%d, nil)", level, err, alert)
+		return
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("Warn")
+	if err != nil || level != WarnLevel {
+		t.Errorf("have:(%s, %v), want:(%s, nil)", level, err, WarnLevel)
+		return
+	}
+	if _, err = ParseLevel("verbose"); err == nil {
+		t.Error("want error")
+		return
+	}
+}
+
+func TestLevel_MarshalText(t *testing.T) {
+	for _, level := range []Level{FatalLevel, ErrorLevel, WarnLevel, InfoLevel, DebugLevel, TraceLevel} {
+		text, err := level.MarshalText()
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if have, want := string(text), level.String(); have != want {
+			t.Errorf("have:%s, want:%s", have, want)
+			return
+		}
+		var have Level
+		if err = have.UnmarshalText(text); err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if have != level {
+			t.Errorf("have:%s, want:%s", have, level)
+			return
+		}
+	}
+	// the invalid levels are encoded as the numbers, only the zero Level is decoded back
+	for _, level := range []Level{invalidLevel, 100} {
+		text, err := level.MarshalText()
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if have, want := string(text), strconv.FormatUint(uint64(level), 10); have != want {
+			t.Errorf("have:%s, want:%s", have, want)
+			return
+		}
+	}
+	{
+		level := InfoLevel
+		if err := level.UnmarshalText([]byte("0")); err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if level != invalidLevel {
+			t.Errorf("have:%d, want:%d", level, invalidLevel)
+			return
+		}
+	}
+	for _, text := range []string{"verbose", "42", "100"} {
+		level := InfoLevel
+		if err := level.UnmarshalText([]byte(text)); err == nil {
+			t.Errorf("text:%s, want error", text)
+			return
+		}
+		if level != InfoLevel {
+			t.Errorf("have:%s, want:%s", level, InfoLevel)
+			return
+		}
+	}
+}
+
+func TestLevel_JSON(t *testing.T) {
+	type config struct {
+		Level Level `json:"level"`
+	}
+	data, err := json.Marshal(config{Level: WarnLevel})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if have, want := string(data), `{"level":"warning"}`; have != want {
+		t.Errorf("have:%s, want:%s", have, want)
+		return
+	}
+	// the zero Level
+	data, err = json.Marshal(config{})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if have, want := string(data), `{"level":0}`; have != want {
+		t.Errorf("have:%s, want:%s", have, want)
+		return
+	}
+
+	tests := []struct {
+		data  string
+		level Level
+		ok    bool
+	}{
+		{`{"level":"warning"}`, WarnLevel, true},
+		{`{"level":"DEBUG"}`, DebugLevel, true},
+		{`{"level":"warn"}`, WarnLevel, true},
+		{`{"level":2}`, ErrorLevel, true},
+		{`{"level":null}`, InfoLevel, true},
+		{`{"level":"verbose"}`, InfoLevel, false},
+		{`{"level":0}`, invalidLevel, true},
+		{`{"level":100}`, InfoLevel, false},
+		{`{"level":1.5}`, InfoLevel, false},
+		{`{"level":true}`, InfoLevel, false},
+	}
+	for _, v := range tests {
+		c := config{Level: InfoLevel}
+		err := json.Unmarshal([]byte(v.data), &c)
+		if c.Level != v.level || (err == nil) != v.ok {
+			t.Errorf("data:%s, have:(%s, %v), want:(%s, %t)", v.data, c.Level, err, v.level, v.ok)
+			return
+		}
+	}
+}
+
+func TestLevelFlag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	level := LevelFlag(fs, "log-level", InfoLevel)
+	other := LevelFlag(fs, "other-level", ErrorLevel)
+	if err := fs.Parse([]string{"-log-level", "WARN"}); err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if *level != WarnLevel || *other != ErrorLevel {
+		t.Errorf("have:(%s, %s), want:(%s, %s)", *level, *other, WarnLevel, ErrorLevel)
+		return
+	}
+	if have, want := fs.Lookup("other-level").DefValue, ErrorLevelString; have != want {
+		t.Errorf("have:%s, want:%s", have, want)
+		return
+	}
+	if err := fs.Parse([]string{"-log-level", "0"}); err == nil {
+		t.Error("want error")
+		return
+	}
+	if err := fs.Parse([]string{"-log-level", "verbose"}); err == nil {
+		t.Error("want error")
+		return
+	}
+}
+
+func TestLevelFlag_Usage(t *testing.T) {
+	defer resetLevelRegistry()
+	MustRegisterLevel(LevelDefinition{Name: "notice", Rank: 350})
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	LevelFlag(fs, "log-level", InfoLevel)
+	have := fs.Lookup("log-level").Usage
+	want := "log level, one of fatal, error, warning, info, debug, trace, notice (or the aliases err, information, warn)"
+	if have != want {
+		t.Errorf("have:%s, want:%s", have, want)
+		return
+	}
+}
+
+func TestLevel_FieldValue(t *testing.T) {
+	entry := &Entry{
+		Level:   InfoLevel,
+		Message: "msg",
+		Fields: map[string]interface{}{
+			"a": WarnLevel,
+			"b": invalidLevel,
+		},
+	}
+	formatter := NewJsonFormatter(WithFieldKeys(FieldKeys{Time: OmitFieldKey, TraceId: OmitFieldKey, Location: OmitFieldKey}))
+	have, err := formatter.Format(entry)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if want := `{"level":"info","msg":"msg","a":"warning","b":0}` + "\n"; string(have) != want {
+		t.Errorf("have:%s, want:%s", have, want)
+		return
+	}
+}
@@ -1,10 +1,11 @@
 package log
 
 import (
-	"path"
 	"runtime"
 	"strconv"
 	"strings"
+
+	internalfields "github.com/chanxuehong/log/internal/fields"
 )
 
 func callerLocation(skip int) string {
@@ -14,23 +15,40 @@ func callerLocation(skip int) string {
 	}
 	fn := runtime.FuncForPC(pc)
 	if fn == nil {
-		return trimFileName(file) + ":" + strconv.Itoa(line)
+		return internalfields.TrimFileName(file) + ":" + strconv.Itoa(line)
 	}
-	return trimFuncName(fn.Name()) + "(" + trimFileName(file) + ":" + strconv.Itoa(line) + ")"
+	return internalfields.TrimFuncName(fn.Name()) + "(" + internalfields.TrimFileName(file) + ":" + strconv.Itoa(line) + ")"
 }
 
-func trimFuncName(name string) string {
-	return path.Base(name)
+// maxStacktraceDepth limits the frames which are captured by callerStacktrace.
+const maxStacktraceDepth = 64
+
+// callerStacktrace returns the stack trace of the caller, one frame per line in the same form as callerLocation,
+// the frames of runtime.main and runtime.goexit are omitted.
+func callerStacktrace(skip int) string {
+	var pcs [maxStacktraceDepth]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	return internalfields.FormatStacktrace(pcs[:n])
 }
 
-func trimFileName(name string) string {
-	i := strings.Index(name, "/pkg/mod/") + len("/pkg/mod/")
-	if i >= len("/pkg/mod/") && i < len(name) /* BCE */ {
-		name = name[i:]
+// splitLocation splits the location returned by callerLocation into the function, file and line.
+//
+// The location is of the form "function(file:line)" or "file:line", the function is empty for the latter.
+func splitLocation(location string) (function, file string, line int, ok bool) {
+	if n := len(location); n > 0 && location[n-1] == ')' {
+		i := strings.LastIndexByte(location, '(')
+		if i < 0 {
+			return "", "", 0, false
+		}
+		function, location = location[:i], location[i+1:n-1]
+	}
+	i := strings.LastIndexByte(location, ':')
+	if i < 0 {
+		return "", "", 0, false
 	}
-	i = strings.LastIndex(name, "/vendor/") + len("/vendor/")
-	if i >= len("/vendor/") && i < len(name) /* BCE */ {
-		return name[i:]
+	line, err := strconv.Atoi(location[i+1:])
+	if err != nil {
+		return "", "", 0, false
 	}
-	return name
+	return function, location[:i], line, true
 }
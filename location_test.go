@@ -20,33 +20,81 @@ func TestCallerLocation(t *testing.T) {
 	}
 }
 
-func TestTrimFileName(t *testing.T) {
+func testCallerStacktrace() string {
+	return callerStacktrace(0)
+}
+
+func TestCallerStacktrace(t *testing.T) {
+	frames := strings.Split(testCallerStacktrace(), "\n")
+	if len(frames) < 2 {
+		t.Errorf("not expected stacktrace: %v", frames)
+		return
+	}
+	if !strings.HasPrefix(frames[0], "log.testCallerStacktrace(") || !strings.HasSuffix(frames[0], "/log/location_test.go:24)") {
+		t.Errorf("not expected frame: %s", frames[0])
+		return
+	}
+	if !strings.HasPrefix(frames[1], "log.TestCallerStacktrace(") {
+		t.Errorf("not expected frame: %s", frames[1])
+		return
+	}
+	for _, frame := range frames {
+		if strings.HasPrefix(frame, "runtime.goexit(") {
+			t.Errorf("not expected frame: %s", frame)
+			return
+		}
+	}
+}
+
+func TestSplitLocation(t *testing.T) {
 	tests := []struct {
-		str  string
-		want string
+		location string
+		function string
+		file     string
+		line     int
+		ok       bool
 	}{
 		{
-			"/a/b/c.go",
-			"/a/b/c.go",
+			"log.testCallerLocation(github.com/chanxuehong/log/location_test.go:9)",
+			"log.testCallerLocation",
+			"github.com/chanxuehong/log/location_test.go",
+			9,
+			true,
+		},
+		{
+			"log.(*logger).Info(github.com/chanxuehong/log/logger.go:163)",
+			"log.(*logger).Info",
+			"github.com/chanxuehong/log/logger.go",
+			163,
+			true,
 		},
 		{
-			"/a/pkg/mod/b/c.go",
-			"b/c.go",
+			"/a/b/c.go:12",
+			"",
+			"/a/b/c.go",
+			12,
+			true,
 		},
 		{
-			"/a/pkg/mod/d/vendor/b/c.go",
-			"b/c.go",
+			"???",
+			"",
+			"",
+			0,
+			false,
 		},
 		{
-			"/a/vendor/b/c.go",
-			"b/c.go",
+			"function(file:line)",
+			"",
+			"",
+			0,
+			false,
 		},
 	}
 
 	for _, v := range tests {
-		have := trimFileName(v.str)
-		if have != v.want {
-			t.Errorf("have:%s, want:%s", have, v.want)
+		function, file, line, ok := splitLocation(v.location)
+		if function != v.function || file != v.file || line != v.line || ok != v.ok {
+			t.Errorf("have:(%s, %s, %d, %t), want:(%s, %s, %d, %t)", function, file, line, ok, v.function, v.file, v.line, v.ok)
 			return
 		}
 	}
@@ -0,0 +1,165 @@
+package log
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// LogfmtFormatter formats the Entry as a logfmt line, that is space-separated key=value pairs,
+// values are quoted and escaped only when needed.
+var LogfmtFormatter Formatter = NewLogfmtFormatter()
+
+// NewLogfmtFormatter returns a Formatter which formats the Entry as a logfmt line.
+func NewLogfmtFormatter(opts ...FormatterOption) Formatter {
+	return logfmtFormatter{opts: newFormatterOptions(opts)}
+}
+
+type logfmtFormatter struct {
+	opts *formatterOptions
+}
+
+func (f logfmtFormatter) Format(entry *Entry) ([]byte, error) {
+	var buffer *bytes.Buffer
+	if entry.Buffer != nil {
+		buffer = entry.Buffer
+	} else {
+		buffer = bytes.NewBuffer(make([]byte, 0, 16<<10))
+	}
+	opts := f.opts
+	if opts.hasTime() {
+		f.appendKeyValue(buffer, opts.keys.Time, opts.formatTime(entry.Time))
+	}
+	if opts.hasLevel() {
+		f.appendKeyValue(buffer, opts.keys.Level, entry.Level.String())
+	}
+	if opts.hasTraceId(entry.TraceId) {
+		f.appendKeyValue(buffer, opts.keys.TraceId, entry.TraceId)
+	}
+	if opts.hasLocation() {
+		f.appendKeyValue(buffer, opts.keys.Location, entry.Location)
+	}
+	if opts.hasMessage() {
+		f.appendKeyValue(buffer, opts.keys.Message, entry.Message)
+	}
+	if fields := entry.Fields; len(fields) > 0 {
+		fixFieldsConflictAndHandleErrorFields(fields, opts.stdKeys)
+
+		flattenFields(fields, opts.stdKeys, opts.flattenDepth, func(key string, value interface{}) {
+			f.appendKeyValue(buffer, key, value)
+		})
+	}
+	buffer.WriteByte('\n')
+	return buffer.Bytes(), nil
+}
+
+func (f logfmtFormatter) appendKeyValue(b *bytes.Buffer, key string, value interface{}) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	appendLogfmtKey(b, key)
+	b.WriteByte('=')
+	f.appendValue(b, value)
+}
+
+func (f logfmtFormatter) appendValue(b *bytes.Buffer, value interface{}) {
+	appendLogfmtValue(b, f.opts.textValue(value))
+}
+
+// appendLogfmtKey writes key to b, the bytes that are not allowed in a logfmt key
+// (space, '=', '"', control characters and invalid UTF-8) are replaced with '_'.
+func appendLogfmtKey(b *bytes.Buffer, key string) {
+	if key == "" {
+		b.WriteByte('_')
+		return
+	}
+	for i := 0; i < len(key); {
+		c := key[i]
+		if c < utf8.RuneSelf {
+			if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+				b.WriteByte('_')
+			} else {
+				b.WriteByte(c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(key[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteByte('_')
+		} else {
+			b.WriteString(key[i : i+size])
+		}
+		i += size
+	}
+}
+
+// appendLogfmtValue writes value to b, quoted and escaped if it contains
+// space, '=', '"', control characters or invalid UTF-8.
+func appendLogfmtValue(b *bytes.Buffer, value string) {
+	if !logfmtNeedsQuote(value) {
+		b.WriteString(value)
+		return
+	}
+	b.WriteByte('"')
+	start := 0
+	for i := 0; i < len(value); {
+		c := value[i]
+		if c < utf8.RuneSelf {
+			if c >= ' ' && c != '"' && c != '\\' && c != 0x7f {
+				i++
+				continue
+			}
+			b.WriteString(value[start:i])
+			switch c {
+			case '"', '\\':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case '\n':
+				b.WriteString(`\n`)
+			case '\r':
+				b.WriteString(`\r`)
+			case '\t':
+				b.WriteString(`\t`)
+			default:
+				b.WriteString(`\u00`)
+				b.WriteByte(hexDigits[c>>4])
+				b.WriteByte(hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(value[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteString(value[start:i])
+			b.WriteString(`\ufffd`)
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b.WriteString(value[start:])
+	b.WriteByte('"')
+}
+
+func logfmtNeedsQuote(value string) bool {
+	for i := 0; i < len(value); {
+		c := value[i]
+		if c < utf8.RuneSelf {
+			if c <= ' ' || c == '=' || c == '"' || c == '\\' || c == 0x7f {
+				return true
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(value[i:])
+		if r == utf8.RuneError && size == 1 {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+const hexDigits = "0123456789abcdef"
@@ -0,0 +1,96 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLogfmtFormatter_Format(t *testing.T) {
+	entry := &Entry{
+		Location: "function(file:line)",
+		Time:     time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+		Level:    InfoLevel,
+		TraceId:  "trace_id_123456789",
+		Message:  "message 123456789",
+		Fields: map[string]interface{}{
+			"key1":           "fields_value1",
+			"key2":           "a, b=c",
+			"key3":           testError{}, // error
+			"key4":           json.RawMessage([]byte(`{"code":0,"msg":""}`)),
+			"key5":           testContextError1{}, // error with ErrorContext
+			"key6":           "line1\nline2\t\\",
+			"key7":           "",
+			"key 8=\"x\"":    123,
+			fieldKeyTime:     "time",
+			fieldKeyLevel:    "level",
+			fieldKeyTraceId:  "request_id",
+			fieldKeyLocation: "location",
+			fieldKeyMessage:  "msg",
+		},
+		Buffer: nil,
+	}
+	have, err := LogfmtFormatter.Format(entry)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	want := `time="2018-05-20 16:20:30.666" level=info request_id=trace_id_123456789 location=function(file:line) msg="message 123456789" ` +
+		`field.level=level field.location=location field.msg=msg field.request_id=request_id field.time=time ` +
+		`key_8__x_=123 key1=fields_value1 key2="a, b=c" key3=test_error_123456789 key4="{\"code\":0,\"msg\":\"\"}" ` +
+		`key5=context_error1_error_123456789 key5_context=context_error1_context_123456789 ` +
+		`key6="line1\nline2\t\\" key7=` + "\n"
+	if string(have) != want {
+		t.Errorf("\nhave:%s\nwant:%s", have, want)
+		return
+	}
+}
+
+func TestAppendLogfmtValue(t *testing.T) {
+	tests := []struct {
+		str  string
+		want string
+	}{
+		{
+			"",
+			``,
+		},
+		{
+			"abc",
+			`abc`,
+		},
+		{
+			"中文",
+			`中文`,
+		},
+		{
+			"a b",
+			`"a b"`,
+		},
+		{
+			"a=b",
+			`"a=b"`,
+		},
+		{
+			`a"b`,
+			`"a\"b"`,
+		},
+		{
+			"a\x00b\x7f",
+			`"a\u0000b\u007f"`,
+		},
+		{
+			"a\xffb",
+			`"a\ufffdb"`,
+		},
+	}
+	for _, v := range tests {
+		var buf bytes.Buffer
+		appendLogfmtValue(&buf, v.str)
+		if have := buf.String(); have != v.want {
+			t.Errorf("str:%q, have:%s, want:%s", v.str, have, v.want)
+			return
+		}
+	}
+}
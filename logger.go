@@ -14,7 +14,8 @@ type Logger interface {
 	// Fatal logs a message at FatalLevel.
 	//
 	// Unlike other golang log libraries (for example, the golang standard log library),
-	// Fatal just logs a message and does not call os.Exit, so you need to explicitly call os.Exit if necessary.
+	// Fatal just logs a message and does not call os.Exit, so you need to explicitly call os.Exit if necessary,
+	// or create the Logger with the WithFatalExit option.
 	//
 	// For fields, the following conditions must be satisfied
 	//  1. the len(fields) must be an even number, that is to say len(fields)%2==0
@@ -40,7 +41,8 @@ type Logger interface {
 	// Output logs a message at specified level.
 	//
 	// For level==FatalLevel, unlike other golang log libraries (for example, the golang standard log library),
-	// Output just logs a message and does not call os.Exit, so you need to explicitly call os.Exit if necessary.
+	// Output just logs a message and does not call os.Exit (unless the Logger is created with the WithFatalExit option),
+	// so you need to explicitly call os.Exit if necessary.
 	//
 	// The requirements for fields can see the comments of Fatal.
 	Output(calldepth int, level Level, msg string, fields ...interface{})
@@ -148,19 +150,19 @@ func (l *logger) setLevel(level Level) {
 }
 
 func (l *logger) Fatal(msg string, fields ...interface{}) {
-	l.output(1, FatalLevel, msg, fields)
+	l.output(1, FatalLevel, msg, fields, true)
 }
 func (l *logger) Error(msg string, fields ...interface{}) {
-	l.output(1, ErrorLevel, msg, fields)
+	l.output(1, ErrorLevel, msg, fields, false)
 }
 func (l *logger) Warn(msg string, fields ...interface{}) {
-	l.output(1, WarnLevel, msg, fields)
+	l.output(1, WarnLevel, msg, fields, false)
 }
 func (l *logger) Info(msg string, fields ...interface{}) {
-	l.output(1, InfoLevel, msg, fields)
+	l.output(1, InfoLevel, msg, fields, false)
 }
 func (l *logger) Debug(msg string, fields ...interface{}) {
-	l.output(1, DebugLevel, msg, fields)
+	l.output(1, DebugLevel, msg, fields, false)
 }
 
 func (l *logger) Output(calldepth int, level Level, msg string, fields ...interface{}) {
@@ -170,19 +172,24 @@ func (l *logger) Output(calldepth int, level Level, msg string, fields ...interf
 	if calldepth < 0 {
 		calldepth = 0
 	}
-	l.output(calldepth+1, level, msg, fields)
+	l.output(calldepth+1, level, msg, fields, level == FatalLevel)
 }
 
-func (l *logger) output(calldepth int, level Level, msg string, fields []interface{}) {
+// output logs the message, exit reports whether the program exits after the entry is written if the Logger
+// is created with the WithFatalExit option, it is false for the panics logged by Recover, Go and RecoverHandler.
+func (l *logger) output(calldepth int, level Level, msg string, fields []interface{}, exit bool) {
 	opts := l.getOptions()
 	if !isLevelEnabled(level, opts.level) {
 		return
 	}
+	if exit && opts.fatalExit {
+		defer fatalExit(opts.output, opts.fallbackOutput, opts.fatalExitCode)
+	}
 	location := callerLocation(calldepth + 1)
 
-	combinedFields, err := combineFields(l.fields, fields)
-	if err != nil {
-		fmt.Fprintf(ConcurrentStderr, "log: failed to combine fields, error=%v, location=%s\n", err, location)
+	combinedFields, fieldsErr := combineFields(l.fields, fields)
+	if isLevelEnabled(level, opts.stacktraceLevel) {
+		combinedFields = addStacktraceField(combinedFields, callerStacktrace(calldepth+1))
 	}
 
 	pool := getBytesBufferPool()
@@ -190,7 +197,7 @@ func (l *logger) output(calldepth int, level Level, msg string, fields []interfa
 	defer pool.Put(buffer)
 	buffer.Reset()
 
-	data, err := opts.formatter.Format(&Entry{
+	entry := &Entry{
 		Location: location,
 		Time:     time.Now(),
 		Level:    level,
@@ -198,13 +205,19 @@ func (l *logger) output(calldepth int, level Level, msg string, fields []interfa
 		Message:  msg,
 		Fields:   combinedFields,
 		Buffer:   buffer,
-	})
+	}
+	if fieldsErr != nil {
+		opts.handleError(ErrorEvent{Phase: ErrorPhaseFields, Err: fieldsErr, Location: location, Entry: entry})
+	}
+	data, err := formatEntry(opts.formatter, entry, opts.colorOutput)
 	if err != nil {
-		fmt.Fprintf(ConcurrentStderr, "log: failed to format Entry, error=%v, location=%s\n", err, location)
+		opts.handleError(ErrorEvent{Phase: ErrorPhaseFormat, Err: err, Location: location, Entry: entry})
+		opts.writeFallback(entry, nil)
 		return
 	}
 	if _, err = opts.output.Write(data); err != nil {
-		fmt.Fprintf(ConcurrentStderr, "log: failed to write to log, error=%v, location=%s\n", err, location)
+		opts.handleError(ErrorEvent{Phase: ErrorPhaseWrite, Err: err, Location: location, Entry: entry})
+		opts.writeFallback(entry, data)
 		return
 	}
 }
@@ -238,7 +251,7 @@ func (l *logger) WithFields(fields ...interface{}) Logger {
 	}
 	m, err := combineFields(l.fields, fields)
 	if err != nil {
-		fmt.Fprintf(ConcurrentStderr, "log: failed to combine fields, error=%v, location=%s\n", err, callerLocation(1))
+		l.getOptions().handleError(ErrorEvent{Phase: ErrorPhaseFields, Err: err, Location: callerLocation(1)})
 	}
 	nl := &logger{
 		fields: m,
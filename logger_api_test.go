@@ -430,7 +430,7 @@ func TestLogger_Output(t *testing.T) {
 		lg.SetOutput(ConcurrentWriter(&buf))
 		lg.SetFormatter(testJsonFormatter{})
 
-		lg.Output(0, DebugLevel+1, "debug-msg", "field1-key", "field1-value", "field2-key", "field2-value")
+		lg.Output(0, TraceLevel+1, "debug-msg", "field1-key", "field1-value", "field2-key", "field2-value")
 		data := buf.Bytes()
 		if len(data) != 0 {
 			t.Errorf("want empty, but now is: %s", data)
@@ -705,7 +705,7 @@ func TestLogger_SetLevel(t *testing.T) {
 		}
 	}
 	{
-		lg.SetLevel(DebugLevel + 1)
+		lg.SetLevel(TraceLevel + 1)
 
 		have := lg.getOptions().level
 		want := InfoLevel
@@ -800,7 +800,7 @@ func TestLogger_SetLevelString(t *testing.T) {
 		}
 	}
 	{
-		lg.SetLevelString("trace")
+		lg.SetLevelString("verbose")
 
 		have := lg.getOptions().level
 		want := InfoLevel
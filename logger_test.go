@@ -1,6 +1,11 @@
 package log
 
-import "testing"
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
 
 func TestLogger_New(t *testing.T) {
 	lg1 := _New([]Option{
@@ -56,3 +61,47 @@ func TestLogger_SetOptions_GetOptions(t *testing.T) {
 		}
 	}
 }
+
+func TestLogger_Stacktrace(t *testing.T) {
+	var buffer bytes.Buffer
+	lg := New(
+		WithFormatter(JsonFormatter),
+		WithOutput(&buffer),
+		WithStacktraceLevel(ErrorLevel),
+	)
+	tests := []struct {
+		output func(msg string, fields ...interface{})
+		want   bool
+	}{
+		{lg.Fatal, true},
+		{lg.Error, true},
+		{lg.Warn, false},
+		{lg.Info, false},
+	}
+	for _, v := range tests {
+		buffer.Reset()
+		v.output("msg", "stacktrace", "value")
+
+		var have map[string]interface{}
+		if err := json.Unmarshal(buffer.Bytes(), &have); err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if !v.want {
+			if have["stacktrace"] != "value" {
+				t.Errorf("have:%v, want:%v", have["stacktrace"], "value")
+				return
+			}
+			continue
+		}
+		stacktrace, _ := have["stacktrace"].(string)
+		if !strings.HasPrefix(stacktrace, "log.TestLogger_Stacktrace(") {
+			t.Errorf("not expected stacktrace: %s", stacktrace)
+			return
+		}
+		if have["field.stacktrace"] != "value" {
+			t.Errorf("have:%v, want:%v", have["field.stacktrace"], "value")
+			return
+		}
+	}
+}
@@ -0,0 +1,409 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	msgpackNil      = 0xc0
+	msgpackFalse    = 0xc2
+	msgpackTrue     = 0xc3
+	msgpackBin8     = 0xc4
+	msgpackBin16    = 0xc5
+	msgpackBin32    = 0xc6
+	msgpackExt8     = 0xc7
+	msgpackExt16    = 0xc8
+	msgpackExt32    = 0xc9
+	msgpackFloat32  = 0xca
+	msgpackFloat64  = 0xcb
+	msgpackUint8    = 0xcc
+	msgpackUint16   = 0xcd
+	msgpackUint32   = 0xce
+	msgpackUint64   = 0xcf
+	msgpackInt8     = 0xd0
+	msgpackInt16    = 0xd1
+	msgpackInt32    = 0xd2
+	msgpackInt64    = 0xd3
+	msgpackFixExt1  = 0xd4
+	msgpackFixExt2  = 0xd5
+	msgpackFixExt4  = 0xd6
+	msgpackFixExt8  = 0xd7
+	msgpackFixExt16 = 0xd8
+	msgpackStr8     = 0xd9
+	msgpackStr16    = 0xda
+	msgpackStr32    = 0xdb
+	msgpackArray16  = 0xdc
+	msgpackArray32  = 0xdd
+	msgpackMap16    = 0xde
+	msgpackMap32    = 0xdf
+
+	msgpackExtTimestamp = -1 // the predefined timestamp extension type
+	msgpackExtDuration  = 1  // time.Duration, the content is the nanoseconds as big-endian int64
+	msgpackExtJSON      = 2  // json.RawMessage, the content is the JSON text
+)
+
+var _ErrMsgpackUnsupported = errors.New("log: unsupported MessagePack format")
+
+// MsgpackFormatter formats the Entry as a MessagePack object, see NewMsgpackDecoder to read it back.
+//
+// The Entry is encoded as the array [version, time, level, traceId, location, message, fields], the level is
+// the number of the built-in level or the name of the custom level (see RegisterLevel), the times are
+// encoded as the timestamp extension type (-1) with nanoseconds, the time.Duration as the extension type 1
+// with the nanoseconds, and the json.RawMessage as the extension type 2.
+// The output is not terminated by a newline since MessagePack objects are self-delimiting.
+var MsgpackFormatter Formatter = msgpackFormatter{}
+
+type msgpackFormatter struct{}
+
+func (msgpackFormatter) Format(entry *Entry) ([]byte, error) {
+	return binaryFormat(msgpackEncoder{}, entry)
+}
+
+type msgpackEncoder struct{}
+
+func (msgpackEncoder) appendNil(b *bytes.Buffer) {
+	b.WriteByte(msgpackNil)
+}
+
+func (msgpackEncoder) appendBool(b *bytes.Buffer, v bool) {
+	if v {
+		b.WriteByte(msgpackTrue)
+	} else {
+		b.WriteByte(msgpackFalse)
+	}
+}
+
+func (e msgpackEncoder) appendInt(b *bytes.Buffer, v int64) {
+	if v >= 0 {
+		e.appendUint(b, uint64(v))
+		return
+	}
+	switch {
+	case v >= -32:
+		b.WriteByte(byte(v))
+	case v >= math.MinInt8:
+		b.WriteByte(msgpackInt8)
+		b.WriteByte(byte(v))
+	case v >= math.MinInt16:
+		appendMsgpackUint16(b, msgpackInt16, uint16(v))
+	case v >= math.MinInt32:
+		appendMsgpackUint32(b, msgpackInt32, uint32(v))
+	default:
+		appendMsgpackUint64(b, msgpackInt64, uint64(v))
+	}
+}
+
+func (msgpackEncoder) appendUint(b *bytes.Buffer, v uint64) {
+	switch {
+	case v <= 0x7f:
+		b.WriteByte(byte(v))
+	case v <= math.MaxUint8:
+		b.WriteByte(msgpackUint8)
+		b.WriteByte(byte(v))
+	case v <= math.MaxUint16:
+		appendMsgpackUint16(b, msgpackUint16, uint16(v))
+	case v <= math.MaxUint32:
+		appendMsgpackUint32(b, msgpackUint32, uint32(v))
+	default:
+		appendMsgpackUint64(b, msgpackUint64, v)
+	}
+}
+
+func (msgpackEncoder) appendFloat32(b *bytes.Buffer, v float32) {
+	appendMsgpackUint32(b, msgpackFloat32, math.Float32bits(v))
+}
+
+func (msgpackEncoder) appendFloat64(b *bytes.Buffer, v float64) {
+	appendMsgpackUint64(b, msgpackFloat64, math.Float64bits(v))
+}
+
+func (msgpackEncoder) appendString(b *bytes.Buffer, v string) {
+	n := len(v)
+	switch {
+	case n < 32:
+		b.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		b.WriteByte(msgpackStr8)
+		b.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		appendMsgpackUint16(b, msgpackStr16, uint16(n))
+	default:
+		appendMsgpackUint32(b, msgpackStr32, uint32(n))
+	}
+	b.WriteString(v)
+}
+
+func (msgpackEncoder) appendBytes(b *bytes.Buffer, v []byte) {
+	n := len(v)
+	switch {
+	case n <= math.MaxUint8:
+		b.WriteByte(msgpackBin8)
+		b.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		appendMsgpackUint16(b, msgpackBin16, uint16(n))
+	default:
+		appendMsgpackUint32(b, msgpackBin32, uint32(n))
+	}
+	b.Write(v)
+}
+
+// appendTime writes v as the timestamp 96 format, that is nanoseconds in uint32 and seconds in int64.
+func (msgpackEncoder) appendTime(b *bytes.Buffer, v time.Time) {
+	var scratch [12]byte
+	binary.BigEndian.PutUint32(scratch[:4], uint32(v.Nanosecond()))
+	binary.BigEndian.PutUint64(scratch[4:], uint64(v.Unix()))
+	b.WriteByte(msgpackExt8)
+	b.WriteByte(12)
+	b.WriteByte(byte(msgpackExtTimestamp & 0xff))
+	b.Write(scratch[:])
+}
+
+func (msgpackEncoder) appendDuration(b *bytes.Buffer, v time.Duration) {
+	var scratch [8]byte
+	binary.BigEndian.PutUint64(scratch[:], uint64(v))
+	b.WriteByte(msgpackFixExt8)
+	b.WriteByte(msgpackExtDuration)
+	b.Write(scratch[:])
+}
+
+func (msgpackEncoder) appendJSON(b *bytes.Buffer, v json.RawMessage) {
+	n := len(v)
+	switch {
+	case n <= math.MaxUint8:
+		b.WriteByte(msgpackExt8)
+		b.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		appendMsgpackUint16(b, msgpackExt16, uint16(n))
+	default:
+		appendMsgpackUint32(b, msgpackExt32, uint32(n))
+	}
+	b.WriteByte(msgpackExtJSON)
+	b.Write(v)
+}
+
+func (msgpackEncoder) appendArrayHeader(b *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		b.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		appendMsgpackUint16(b, msgpackArray16, uint16(n))
+	default:
+		appendMsgpackUint32(b, msgpackArray32, uint32(n))
+	}
+}
+
+func (msgpackEncoder) appendMapHeader(b *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		b.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		appendMsgpackUint16(b, msgpackMap16, uint16(n))
+	default:
+		appendMsgpackUint32(b, msgpackMap32, uint32(n))
+	}
+}
+
+func appendMsgpackUint16(b *bytes.Buffer, c byte, v uint16) {
+	var scratch [2]byte
+	binary.BigEndian.PutUint16(scratch[:], v)
+	b.WriteByte(c)
+	b.Write(scratch[:])
+}
+
+func appendMsgpackUint32(b *bytes.Buffer, c byte, v uint32) {
+	var scratch [4]byte
+	binary.BigEndian.PutUint32(scratch[:], v)
+	b.WriteByte(c)
+	b.Write(scratch[:])
+}
+
+func appendMsgpackUint64(b *bytes.Buffer, c byte, v uint64) {
+	var scratch [8]byte
+	binary.BigEndian.PutUint64(scratch[:], v)
+	b.WriteByte(c)
+	b.Write(scratch[:])
+}
+
+type msgpackDecoder struct{}
+
+// readUint reads a big-endian unsigned integer of size bytes.
+func (msgpackDecoder) readUint(r *bufio.Reader, size int) (uint64, error) {
+	var scratch [8]byte
+	if _, err := io.ReadFull(r, scratch[8-size:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(scratch[:]), nil
+}
+
+func (d msgpackDecoder) decodeValue(r *bufio.Reader, depth int) (interface{}, error) {
+	if depth > binaryMaxDepth {
+		return nil, _ErrBinaryMaxDepth
+	}
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c <= 0x7f: // positive fixint
+		return int64(c), nil
+	case c >= 0xe0: // negative fixint
+		return int64(int8(c)), nil
+	case c&0xe0 == 0xa0: // fixstr
+		return d.decodeString(r, uint64(c&0x1f))
+	case c&0xf0 == 0x90: // fixarray
+		return d.decodeArray(r, uint64(c&0x0f), depth)
+	case c&0xf0 == 0x80: // fixmap
+		return d.decodeMap(r, uint64(c&0x0f), depth)
+	}
+
+	switch c {
+	case msgpackNil:
+		return nil, nil
+	case msgpackFalse:
+		return false, nil
+	case msgpackTrue:
+		return true, nil
+	case msgpackUint8, msgpackUint16, msgpackUint32, msgpackUint64:
+		n, err := d.readUint(r, 1<<(c-msgpackUint8))
+		if err != nil {
+			return nil, err
+		}
+		return binaryInt(n), nil
+	case msgpackInt8, msgpackInt16, msgpackInt32, msgpackInt64:
+		size := 1 << (c - msgpackInt8)
+		n, err := d.readUint(r, size)
+		if err != nil {
+			return nil, err
+		}
+		shift := uint(64 - 8*size)
+		return int64(n<<shift) >> shift, nil
+	case msgpackFloat32:
+		n, err := d.readUint(r, 4)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(uint32(n)), nil
+	case msgpackFloat64:
+		n, err := d.readUint(r, 8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(n), nil
+	case msgpackStr8, msgpackStr16, msgpackStr32:
+		n, err := d.readUint(r, 1<<(c-msgpackStr8))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(r, n)
+	case msgpackBin8, msgpackBin16, msgpackBin32:
+		n, err := d.readUint(r, 1<<(c-msgpackBin8))
+		if err != nil {
+			return nil, err
+		}
+		return readBinaryBytes(r, n)
+	case msgpackArray16, msgpackArray32:
+		n, err := d.readUint(r, 2<<(c-msgpackArray16))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(r, n, depth)
+	case msgpackMap16, msgpackMap32:
+		n, err := d.readUint(r, 2<<(c-msgpackMap16))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(r, n, depth)
+	case msgpackFixExt1, msgpackFixExt2, msgpackFixExt4, msgpackFixExt8, msgpackFixExt16:
+		return d.decodeExt(r, 1<<(c-msgpackFixExt1))
+	case msgpackExt8, msgpackExt16, msgpackExt32:
+		n, err := d.readUint(r, 1<<(c-msgpackExt8))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeExt(r, n)
+	default:
+		return nil, _ErrMsgpackUnsupported
+	}
+}
+
+func (msgpackDecoder) decodeString(r *bufio.Reader, n uint64) (interface{}, error) {
+	data, err := readBinaryBytes(r, n)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (d msgpackDecoder) decodeArray(r *bufio.Reader, n uint64, depth int) (interface{}, error) {
+	if n > binaryMaxLength {
+		return nil, _ErrBinaryMaxLength
+	}
+	array := make([]interface{}, 0, minInt(int(n), 1024))
+	for i := uint64(0); i < n; i++ {
+		elem, err := d.decodeValue(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, elem)
+	}
+	return array, nil
+}
+
+func (d msgpackDecoder) decodeMap(r *bufio.Reader, n uint64, depth int) (interface{}, error) {
+	if n > binaryMaxLength {
+		return nil, _ErrBinaryMaxLength
+	}
+	m := make(map[string]interface{}, minInt(int(n), 1024))
+	for i := uint64(0); i < n; i++ {
+		key, err := d.decodeValue(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		k, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("log: MessagePack map key must be string, have %T", key)
+		}
+		if m[k], err = d.decodeValue(r, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (msgpackDecoder) decodeExt(r *bufio.Reader, n uint64) (interface{}, error) {
+	typ, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	data, err := readBinaryBytes(r, n)
+	if err != nil {
+		return nil, err
+	}
+	switch int8(typ) {
+	case msgpackExtTimestamp:
+		switch len(data) {
+		case 4: // timestamp 32
+			return time.Unix(int64(binary.BigEndian.Uint32(data)), 0), nil
+		case 8: // timestamp 64
+			v := binary.BigEndian.Uint64(data)
+			return time.Unix(int64(v&(1<<34-1)), int64(v>>34)), nil
+		case 12: // timestamp 96
+			return time.Unix(int64(binary.BigEndian.Uint64(data[4:])), int64(binary.BigEndian.Uint32(data[:4]))), nil
+		}
+	case msgpackExtDuration:
+		if len(data) == 8 {
+			return time.Duration(binary.BigEndian.Uint64(data)), nil
+		}
+	case msgpackExtJSON:
+		return json.RawMessage(data), nil
+	}
+	return nil, fmt.Errorf("log: unsupported MessagePack extension type %d with length %d", int8(typ), len(data))
+}
@@ -0,0 +1,34 @@
+package log
+
+import internalfields "github.com/chanxuehong/log/internal/fields"
+
+// ObjectMarshaler is implemented by the types which write their own fields, for example
+//
+//	func (u *User) MarshalLogObject(enc log.FieldEncoder) error {
+//		enc.AddString("name", u.Name)
+//		enc.AddInt64("age", int64(u.Age))
+//		return nil // the password is not written
+//	}
+//
+// The field value which implements ObjectMarshaler is resolved into a map[string]interface{} before formatting,
+// so it is written as nested fields by all the formatters, for example user.name=Alice by TextFormatter and
+// {"user":{"name":"Alice"}} by JsonFormatter. If MarshalLogObject returns an error, the fields added
+// before the error are written, and the error message is added as the "<key>_error" field.
+type ObjectMarshaler = internalfields.ObjectMarshaler
+
+// FieldEncoder is used by ObjectMarshaler to add the fields, the field added later overwrites the one
+// with the same key.
+//
+//	AddString(key, value string)
+//	AddInt64(key string, value int64)
+//	AddUint64(key string, value uint64)
+//	AddFloat64(key string, value float64)
+//	AddBool(key string, value bool)
+//	AddTime(key string, value time.Time)
+//	AddDuration(key string, value time.Duration)
+//	AddObject(key string, value ObjectMarshaler) error
+//	Add(key string, value interface{})
+//
+// AddObject adds the nested object, the error returned by its MarshalLogObject is returned.
+// Add adds the value of any other type, it is written the same as the field value of Entry.Fields.
+type FieldEncoder = internalfields.FieldEncoder
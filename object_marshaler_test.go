@@ -0,0 +1,167 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	internalfields "github.com/chanxuehong/log/internal/fields"
+)
+
+type testUser struct {
+	Name     string
+	Age      int
+	Password string
+	Address  *testAddress
+}
+
+func (u *testUser) MarshalLogObject(enc FieldEncoder) error {
+	enc.AddString("name", u.Name)
+	enc.AddInt64("age", int64(u.Age))
+	return enc.AddObject("address", u.Address)
+}
+
+type testAddress struct {
+	City string
+}
+
+func (a *testAddress) MarshalLogObject(enc FieldEncoder) error {
+	enc.AddString("city", a.City)
+	return nil
+}
+
+type testOrder struct{}
+
+func (testOrder) MarshalLogObject(enc FieldEncoder) error {
+	enc.AddUint64("id", 1)
+	enc.AddFloat64("amount", 9.5)
+	enc.AddBool("paid", true)
+	enc.AddTime("created", time.Date(2018, time.May, 20, 8, 20, 30, 0, time.UTC))
+	enc.AddDuration("elapsed", time.Second)
+	enc.Add("items", []string{"a", "b"})
+	enc.Add("buyer", &testUser{Name: "Bob"})
+	return errors.New("order_error_123456789")
+}
+
+type testRecursiveObject struct{}
+
+func (o testRecursiveObject) MarshalLogObject(enc FieldEncoder) error {
+	return enc.AddObject("next", o)
+}
+
+func TestFixFieldsConflictAndHandleErrorFields_ObjectMarshaler(t *testing.T) {
+	var nilUser *testUser
+	fields := map[string]interface{}{
+		"user":     &testUser{Name: "Alice", Age: 30, Password: "secret", Address: &testAddress{City: "Paris"}},
+		"order":    testOrder{},
+		"nil_user": nilUser,
+	}
+	fixFieldsConflictAndHandleErrorFields(fields, stdFieldKeys)
+	want := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name":    "Alice",
+			"age":     int64(30),
+			"address": map[string]interface{}{"city": "Paris"},
+		},
+		"order": map[string]interface{}{
+			"id":      uint64(1),
+			"amount":  9.5,
+			"paid":    true,
+			"created": time.Date(2018, time.May, 20, 8, 20, 30, 0, time.UTC),
+			"elapsed": time.Second,
+			"items":   []string{"a", "b"},
+			"buyer": map[string]interface{}{
+				"name":    "Bob",
+				"age":     int64(0),
+				"address": nil,
+			},
+		},
+		"order_error": "order_error_123456789",
+		"nil_user":    nil,
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("\nhave:%v\nwant:%v", fields, want)
+		return
+	}
+}
+
+func TestFixFieldsConflictAndHandleErrorFields_ObjectMarshalerDepth(t *testing.T) {
+	fields := map[string]interface{}{
+		"object": testRecursiveObject{},
+	}
+	fixFieldsConflictAndHandleErrorFields(fields, stdFieldKeys)
+	if have, want := fields["object_error"], internalfields.ErrObjectTooDeep.Error(); have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+}
+
+type testPanicObject struct{}
+
+func (testPanicObject) MarshalLogObject(enc FieldEncoder) error {
+	enc.AddString("name", "Alice")
+	panic("object_panic_123456789")
+}
+
+func TestFixFieldsConflictAndHandleErrorFields_ObjectMarshalerPanic(t *testing.T) {
+	fields := map[string]interface{}{
+		"object": testPanicObject{},
+	}
+	fixFieldsConflictAndHandleErrorFields(fields, stdFieldKeys)
+	want := map[string]interface{}{
+		"object":       map[string]interface{}{"name": "Alice"},
+		"object_error": "panic: object_panic_123456789",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("\nhave:%v\nwant:%v", fields, want)
+		return
+	}
+}
+
+func TestObjectMarshaler_Format(t *testing.T) {
+	user := &testUser{Name: "Alice", Age: 30, Password: "secret", Address: &testAddress{City: "Paris"}}
+	newEntry := func() *Entry {
+		return &Entry{
+			Time:    time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+			Level:   InfoLevel,
+			Message: "msg",
+			Fields:  map[string]interface{}{"user": user},
+		}
+	}
+
+	data, err := TextFormatter.Format(newEntry())
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	want := `user.address.city=Paris, user.age=30, user.name=Alice` + "\n"
+	if have := string(data); !strings.HasSuffix(have, want) || strings.Contains(have, "secret") {
+		t.Errorf("\nhave:%s\nwant suffix:%s", have, want)
+		return
+	}
+
+	data, err = JsonFormatter.Format(newEntry())
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	var have struct {
+		User map[string]interface{} `json:"user"`
+	}
+	if err = json.Unmarshal(data, &have); err != nil {
+		t.Error(err.Error())
+		return
+	}
+	wantUser := map[string]interface{}{
+		"name":    "Alice",
+		"age":     float64(30),
+		"address": map[string]interface{}{"city": "Paris"},
+	}
+	if !reflect.DeepEqual(have.User, wantUser) {
+		t.Errorf("\nhave:%v\nwant:%v", have.User, wantUser)
+		return
+	}
+}
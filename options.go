@@ -62,11 +62,67 @@ func WithLevelString(str string) Option {
 	}
 }
 
+// WithStacktraceLevel captures the stack trace of the caller for the entries at level or higher (more severe),
+// and writes it as the "stacktrace" field. By default the stack trace is not captured.
+func WithStacktraceLevel(level Level) Option {
+	return func(o *options) {
+		if !isValidLevel(level) {
+			return
+		}
+		o.stacktraceLevel = level
+	}
+}
+
+// WithFatalExit makes the Logger exit the program with code after a Fatal entry is written,
+// the output and the fallback output (see WithFallbackOutput) are flushed (if they have the Flush() error
+// or Sync() error method) and the handlers registered by RegisterExitHandler are called before exiting, see ExitFunc.
+//
+// Only Fatal (and Output at FatalLevel) exits, the panics logged at FatalLevel by Recover, Go and RecoverHandler
+// do not. By default Fatal does not exit the program.
+func WithFatalExit(code int) Option {
+	return func(o *options) {
+		o.fatalExit = true
+		o.fatalExitCode = code
+	}
+}
+
+// WithErrorHandler sets the handler which is called when an error occurs while logging,
+// by default the error is written to ConcurrentStderr. The handler must be thread-safe.
+func WithErrorHandler(handler func(ErrorEvent)) Option {
+	return func(o *options) {
+		if handler == nil {
+			o.errorHandler = nil
+			return
+		}
+		o.errorHandler = &handler
+	}
+}
+
+// WithFallbackOutput sets the output which the Entry is written to when the formatter or the output fails,
+// for example when the disk is full. The Entry is formatted again by formatter, if formatter is nil,
+// the formatted data is written as is, or the Entry is formatted by TextFormatter if the formatter failed.
+//  NOTE: output must be thread-safe, see ConcurrentWriter.
+func WithFallbackOutput(output io.Writer, formatter Formatter) Option {
+	return func(o *options) {
+		o.fallbackOutput = output
+		o.fallbackFormatter = formatter
+	}
+}
+
 type options struct {
-	traceId   string
-	formatter Formatter
-	output    io.Writer
-	level     Level
+	traceId         string
+	formatter       Formatter
+	output          io.Writer
+	level           Level
+	stacktraceLevel Level // invalidLevel means the stack trace is not captured
+	fatalExit       bool
+	fatalExitCode   int
+
+	errorHandler      *func(ErrorEvent) // pointer to keep options comparable
+	fallbackOutput    io.Writer
+	fallbackFormatter Formatter
+
+	colorOutput bool // output is a color terminal and formatter is a colorFormatter, see setColorOutput
 }
 
 func (opts *options) SetFormatter(formatter Formatter) {
@@ -74,12 +130,25 @@ func (opts *options) SetFormatter(formatter Formatter) {
 		return
 	}
 	opts.formatter = formatter
+	opts.setColorOutput()
 }
 func (opts *options) SetOutput(output io.Writer) {
 	if output == nil {
 		return
 	}
 	opts.output = output
+	opts.setColorOutput()
+}
+
+// setColorOutput updates colorOutput after formatter or output is changed, the output is checked
+// (see isColorOutput, which reads the environment variables and stats the file) only if formatter
+// writes the color automatically, see colorFormatter.
+func (opts *options) setColorOutput() {
+	if _, ok := opts.formatter.(colorFormatter); !ok {
+		opts.colorOutput = false
+		return
+	}
+	opts.colorOutput = isColorOutput(opts.output)
 }
 func (opts *options) SetLevel(level Level) {
 	if !isValidLevel(level) {
@@ -108,6 +177,7 @@ func newOptions(opts []Option) *options {
 	if o.output == nil {
 		o.output = ConcurrentStdout
 	}
+	o.setColorOutput()
 	if o.level == invalidLevel {
 		o.level = DebugLevel
 	}
@@ -116,6 +186,8 @@ func newOptions(opts []Option) *options {
 
 var _defaultOptionsPtr unsafe.Pointer // *[]Option
 
+// SetDefaultOptions sets the options which are applied before the options of New,
+// it does not change the existing loggers including the standard logger, see SetOptions.
 func SetDefaultOptions(opts []Option) {
 	if opts == nil {
 		atomic.StorePointer(&_defaultOptionsPtr, nil)
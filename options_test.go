@@ -265,9 +265,9 @@ func TestWithLevel(t *testing.T) {
 			return
 		}
 	}
-	// debug+1
+	// trace+1
 	{
-		opt := WithLevel(DebugLevel + 1)
+		opt := WithLevel(TraceLevel + 1)
 
 		var o = options{
 			level: FatalLevel,
@@ -283,6 +283,34 @@ func TestWithLevel(t *testing.T) {
 	}
 }
 
+func TestWithStacktraceLevel(t *testing.T) {
+	tests := []struct {
+		level Level
+		want  Level
+	}{
+		{FatalLevel - 1, WarnLevel},
+		{FatalLevel, FatalLevel},
+		{ErrorLevel, ErrorLevel},
+		{DebugLevel, DebugLevel},
+		{TraceLevel + 1, WarnLevel},
+	}
+	for _, v := range tests {
+		opt := WithStacktraceLevel(v.level)
+
+		var o = options{
+			stacktraceLevel: WarnLevel,
+		}
+		opt(&o)
+		want := options{
+			stacktraceLevel: v.want,
+		}
+		if o != want {
+			t.Errorf("have:%+v, want:%+v", o, want)
+			return
+		}
+	}
+}
+
 func TestWithLevelString(t *testing.T) {
 	// panic
 	{
@@ -380,9 +408,9 @@ func TestWithLevelString(t *testing.T) {
 			return
 		}
 	}
-	// trace
+	// verbose
 	{
-		opt := WithLevelString("trace")
+		opt := WithLevelString("verbose")
 
 		var o = options{
 			level: FatalLevel,
@@ -557,12 +585,12 @@ func TestOptions_SetLevel(t *testing.T) {
 			return
 		}
 	}
-	// debug+1
+	// trace+1
 	{
 		var o = options{
 			level: FatalLevel,
 		}
-		o.SetLevel(DebugLevel + 1)
+		o.SetLevel(TraceLevel + 1)
 
 		want := options{
 			level: FatalLevel,
@@ -0,0 +1,306 @@
+package log
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// OtelScopeName is the name of the InstrumentationScope which is written by OtelFormatter.
+const OtelScopeName = "github.com/chanxuehong/log"
+
+// otelFieldKeys are the attribute keys which are written by OtelFormatter besides the fields.
+var otelFieldKeys = []string{
+	fieldKeyTraceId,
+	"code.function",
+	"code.filepath",
+	"code.lineno",
+}
+
+// OtelFormatter formats the Entry as an OpenTelemetry LogRecord in OTLP/JSON.
+//
+// Entry.Time is written as timeUnixNano, the Level as severityNumber and severityText, Entry.Message as body,
+// Entry.TraceId as traceId if it is 32 hex digits or else as the request_id attribute, Entry.Location as the
+// code.function, code.filepath and code.lineno attributes, and Entry.Fields as the attributes with typed AnyValue.
+var OtelFormatter Formatter = NewOtelFormatter()
+
+// BatchFormatter is implemented by the formatters which can format several entries as one message,
+// for example the Formatter returned by NewOtelFormatter.
+type BatchFormatter interface {
+	Formatter
+
+	// FormatBatch formats entries as one message, Entry.Buffer is not used.
+	FormatBatch(entries []*Entry) ([]byte, error)
+}
+
+// NewOtelFormatter returns a Formatter which formats the Entry the same as OtelFormatter.
+//
+// If the resource attributes are set by WithResource, each Entry is wrapped in resourceLogs,
+// that is a complete ExportLogsServiceRequest per line.
+// The returned Formatter implements BatchFormatter.
+func NewOtelFormatter(opts ...FormatterOption) Formatter {
+	return otelFormatter{opts: newFormatterOptions(opts)}
+}
+
+type otelFormatter struct {
+	opts *formatterOptions
+}
+
+var _ BatchFormatter = otelFormatter{}
+
+func (f otelFormatter) Format(entry *Entry) ([]byte, error) {
+	var buffer *bytes.Buffer
+	if entry.Buffer != nil {
+		buffer = entry.Buffer
+	} else {
+		buffer = bytes.NewBuffer(make([]byte, 0, 16<<10))
+	}
+	if f.opts.resource == nil {
+		f.appendLogRecord(buffer, entry)
+	} else {
+		f.appendResourceLogs(buffer, []*Entry{entry})
+	}
+	buffer.WriteByte('\n')
+	return buffer.Bytes(), nil
+}
+
+// FormatBatch formats the entries as one ExportLogsServiceRequest in OTLP/JSON,
+// the entries are wrapped in resourceLogs with the resource attributes set by WithResource.
+func (f otelFormatter) FormatBatch(entries []*Entry) ([]byte, error) {
+	buffer := bytes.NewBuffer(make([]byte, 0, 16<<10))
+	f.appendResourceLogs(buffer, entries)
+	buffer.WriteByte('\n')
+	return buffer.Bytes(), nil
+}
+
+func (f otelFormatter) appendResourceLogs(b *bytes.Buffer, entries []*Entry) {
+	b.WriteString(`{"resourceLogs":[{"resource":{"attributes":`)
+	appendOtelAttributes(b, f.opts.resource)
+	b.WriteString(`},"scopeLogs":[{"scope":{"name":`)
+	appendJSONString(b, OtelScopeName)
+	b.WriteString(`},"logRecords":[`)
+	for i, entry := range entries {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		f.appendLogRecord(b, entry)
+	}
+	b.WriteString(`]}]}]}`)
+}
+
+func (f otelFormatter) appendLogRecord(b *bytes.Buffer, entry *Entry) {
+	var scratch [64]byte
+
+	b.WriteByte('{')
+	start := b.Len()
+	appendJSONKey(b, "timeUnixNano", start)
+	b.WriteByte('"')
+	if entry.Time.Unix() < 0 {
+		b.WriteByte('0') // timeUnixNano is unsigned, 0 means the time is unknown
+	} else {
+		b.Write(appendUnixNanos(scratch[:0], entry.Time))
+	}
+	b.WriteByte('"')
+	appendJSONKey(b, "severityNumber", start)
+	b.Write(strconv.AppendInt(scratch[:0], int64(entry.Level.OtelSeverityNumber()), 10))
+	appendJSONKey(b, "severityText", start)
+	appendJSONString(b, entry.Level.String())
+	appendJSONKey(b, "body", start)
+	appendOtelAnyValue(b, entry.Message)
+
+	fields := entry.Fields
+	if len(fields) > 0 {
+		fixFieldsConflictAndHandleErrorFields(fields, otelFieldKeys)
+	}
+	isTraceId := isOtelTraceId(entry.TraceId)
+	function, file, line, hasLocation := splitLocation(entry.Location)
+	if len(fields) > 0 || hasLocation || (entry.TraceId != "" && !isTraceId) {
+		appendJSONKey(b, "attributes", start)
+		b.WriteByte('[')
+		attributesStart := b.Len()
+		if entry.TraceId != "" && !isTraceId {
+			appendOtelKeyValue(b, fieldKeyTraceId, entry.TraceId, attributesStart)
+		}
+		if hasLocation {
+			if function != "" {
+				appendOtelKeyValue(b, "code.function", function, attributesStart)
+			}
+			appendOtelKeyValue(b, "code.filepath", file, attributesStart)
+			appendOtelKeyValue(b, "code.lineno", line, attributesStart)
+		}
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			appendOtelKeyValue(b, k, fields[k], attributesStart)
+		}
+		b.WriteByte(']')
+	}
+	if isTraceId {
+		appendJSONKey(b, "traceId", start)
+		appendJSONString(b, entry.TraceId)
+	}
+	b.WriteByte('}')
+}
+
+// isOtelTraceId reports whether traceId is a valid OpenTelemetry trace id, that is 32 lowercase hex digits and not all zero.
+func isOtelTraceId(traceId string) bool {
+	if len(traceId) != 32 {
+		return false
+	}
+	allZero := true
+	for i := 0; i < len(traceId); i++ {
+		c := traceId[i]
+		switch {
+		case c == '0':
+		case c >= '1' && c <= '9', c >= 'a' && c <= 'f':
+			allZero = false
+		default:
+			return false
+		}
+	}
+	return !allZero
+}
+
+// appendOtelAttributes writes m as an array of KeyValue sorted by key.
+func appendOtelAttributes(b *bytes.Buffer, m map[string]interface{}) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b.WriteByte('[')
+	start := b.Len()
+	for _, k := range keys {
+		appendOtelKeyValue(b, k, m[k], start)
+	}
+	b.WriteByte(']')
+}
+
+// appendOtelKeyValue writes a KeyValue, start is the length of b just after the opening bracket of the array.
+func appendOtelKeyValue(b *bytes.Buffer, key string, value interface{}, start int) {
+	if b.Len() > start {
+		b.WriteByte(',')
+	}
+	b.WriteString(`{"key":`)
+	appendJSONString(b, key)
+	b.WriteString(`,"value":`)
+	appendOtelAnyValue(b, value)
+	b.WriteByte('}')
+}
+
+// appendOtelAnyValue writes value as an AnyValue, the 64-bit integers are written as strings
+// and the bytes are written as base64 as the protobuf JSON mapping requires.
+func appendOtelAnyValue(b *bytes.Buffer, value interface{}) {
+	var scratch [64]byte
+	switch v := value.(type) {
+	case nil:
+		b.WriteString(`{}`)
+	case string:
+		b.WriteString(`{"stringValue":`)
+		appendJSONString(b, v)
+		b.WriteByte('}')
+	case bool:
+		b.WriteString(`{"boolValue":`)
+		b.Write(strconv.AppendBool(scratch[:0], v))
+		b.WriteByte('}')
+	case int:
+		appendOtelIntValue(b, int64(v))
+	case int8:
+		appendOtelIntValue(b, int64(v))
+	case int16:
+		appendOtelIntValue(b, int64(v))
+	case int32:
+		appendOtelIntValue(b, int64(v))
+	case int64:
+		appendOtelIntValue(b, v)
+	case uint:
+		appendOtelUintValue(b, uint64(v))
+	case uint8:
+		appendOtelIntValue(b, int64(v))
+	case uint16:
+		appendOtelIntValue(b, int64(v))
+	case uint32:
+		appendOtelIntValue(b, int64(v))
+	case uint64:
+		appendOtelUintValue(b, v)
+	case float32:
+		appendOtelDoubleValue(b, float64(v))
+	case float64:
+		appendOtelDoubleValue(b, v)
+	case time.Duration:
+		appendOtelIntValue(b, int64(v))
+	case time.Time:
+		b.WriteString(`{"stringValue":"`)
+		b.Write(v.AppendFormat(scratch[:0], time.RFC3339Nano))
+		b.WriteString(`"}`)
+	case []byte:
+		b.WriteString(`{"bytesValue":"`)
+		encoder := base64.NewEncoder(base64.StdEncoding, b)
+		encoder.Write(v)
+		encoder.Close()
+		b.WriteString(`"}`)
+	case json.RawMessage:
+		b.WriteString(`{"stringValue":`)
+		appendJSONString(b, string(v))
+		b.WriteByte('}')
+	case []interface{}:
+		b.WriteString(`{"arrayValue":{"values":[`)
+		for i, elem := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			appendOtelAnyValue(b, elem)
+		}
+		b.WriteString(`]}}`)
+	case map[string]interface{}:
+		b.WriteString(`{"kvlistValue":{"values":`)
+		appendOtelAttributes(b, v)
+		b.WriteString(`}}`)
+	default:
+		b.WriteString(`{"stringValue":`)
+		appendJSONString(b, textValue(value))
+		b.WriteByte('}')
+	}
+}
+
+func appendOtelIntValue(b *bytes.Buffer, v int64) {
+	var scratch [24]byte
+	b.WriteString(`{"intValue":"`)
+	b.Write(strconv.AppendInt(scratch[:0], v, 10))
+	b.WriteString(`"}`)
+}
+
+func appendOtelUintValue(b *bytes.Buffer, v uint64) {
+	if v > math.MaxInt64 {
+		var scratch [24]byte
+		b.WriteString(`{"stringValue":"`)
+		b.Write(strconv.AppendUint(scratch[:0], v, 10))
+		b.WriteString(`"}`)
+		return
+	}
+	appendOtelIntValue(b, int64(v))
+}
+
+// appendOtelDoubleValue writes v as a doubleValue, NaN and Infinity are written as strings as the protobuf JSON mapping requires.
+func appendOtelDoubleValue(b *bytes.Buffer, v float64) {
+	b.WriteString(`{"doubleValue":`)
+	switch {
+	case math.IsNaN(v):
+		b.WriteString(`"NaN"`)
+	case math.IsInf(v, 1):
+		b.WriteString(`"Infinity"`)
+	case math.IsInf(v, -1):
+		b.WriteString(`"-Infinity"`)
+	default:
+		appendJSONFloat(b, v, 64)
+	}
+	b.WriteByte('}')
+}
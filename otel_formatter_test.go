@@ -0,0 +1,134 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOtelFormatter_Format(t *testing.T) {
+	entry := &Entry{
+		Location: "log.testFunc(github.com/chanxuehong/log/otel_formatter_test.go:20)",
+		Time:     time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+		Level:    WarnLevel,
+		TraceId:  "5b8efff798038103d269b633813fc60c",
+		Message:  "message_123456789",
+		Fields: map[string]interface{}{
+			"string":   "fields_value1",
+			"int":      -2,
+			"uint64":   uint64(math.MaxUint64),
+			"float":    3.5,
+			"nan":      math.NaN(),
+			"bool":     true,
+			"bytes":    []byte("abc"),
+			"duration": time.Second,
+			"nil":      nil,
+			"array":    []interface{}{"a", 1},
+			"map":      map[string]interface{}{"b": false},
+			"error":    testError{},
+		},
+		Buffer: nil,
+	}
+	have, err := NewOtelFormatter().Format(entry)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	want := `{"timeUnixNano":"1526804430666777888","severityNumber":13,"severityText":"warning","body":{"stringValue":"message_123456789"},` +
+		`"attributes":[{"key":"code.function","value":{"stringValue":"log.testFunc"}},` +
+		`{"key":"code.filepath","value":{"stringValue":"github.com/chanxuehong/log/otel_formatter_test.go"}},` +
+		`{"key":"code.lineno","value":{"intValue":"20"}},` +
+		`{"key":"array","value":{"arrayValue":{"values":[{"stringValue":"a"},{"intValue":"1"}]}}},` +
+		`{"key":"bool","value":{"boolValue":true}},` +
+		`{"key":"bytes","value":{"bytesValue":"YWJj"}},` +
+		`{"key":"duration","value":{"intValue":"1000000000"}},` +
+		`{"key":"error","value":{"stringValue":"test_error_123456789"}},` +
+		`{"key":"float","value":{"doubleValue":3.5}},` +
+		`{"key":"int","value":{"intValue":"-2"}},` +
+		`{"key":"map","value":{"kvlistValue":{"values":[{"key":"b","value":{"boolValue":false}}]}}},` +
+		`{"key":"nan","value":{"doubleValue":"NaN"}},` +
+		`{"key":"nil","value":{}},` +
+		`{"key":"string","value":{"stringValue":"fields_value1"}},` +
+		`{"key":"uint64","value":{"stringValue":"18446744073709551615"}}],` +
+		`"traceId":"5b8efff798038103d269b633813fc60c"}` + "\n"
+	if string(have) != want {
+		t.Errorf("\nhave:%s\nwant:%s", have, want)
+		return
+	}
+}
+
+func TestOtelFormatter_FormatBatch(t *testing.T) {
+	formatter, ok := NewOtelFormatter(WithResource(map[string]interface{}{"service.name": "my-service"})).(BatchFormatter)
+	if !ok {
+		t.Error("want BatchFormatter")
+		return
+	}
+	newEntry := func(msg string) *Entry {
+		return &Entry{
+			Time:    time.Date(2018, time.May, 20, 8, 20, 30, 0, time.UTC),
+			Level:   InfoLevel,
+			TraceId: "request_id_123456789",
+			Message: msg,
+		}
+	}
+	want := `{"resourceLogs":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"my-service"}}]},` +
+		`"scopeLogs":[{"scope":{"name":"github.com/chanxuehong/log"},"logRecords":[` +
+		`{"timeUnixNano":"1526804430000000000","severityNumber":9,"severityText":"info","body":{"stringValue":"1"},` +
+		`"attributes":[{"key":"request_id","value":{"stringValue":"request_id_123456789"}}]}` +
+		`%s]}]}]}` + "\n"
+
+	// Format
+	{
+		have, err := formatter.Format(newEntry("1"))
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if want := fmt.Sprintf(want, ""); string(have) != want {
+			t.Errorf("\nhave:%s\nwant:%s", have, want)
+			return
+		}
+	}
+	// FormatBatch
+	{
+		have, err := formatter.FormatBatch([]*Entry{newEntry("1"), newEntry("2")})
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if !json.Valid(have) {
+			t.Errorf("invalid JSON: %s", have)
+			return
+		}
+		second := `,{"timeUnixNano":"1526804430000000000","severityNumber":9,"severityText":"info","body":{"stringValue":"2"},` +
+			`"attributes":[{"key":"request_id","value":{"stringValue":"request_id_123456789"}}]}`
+		if want := fmt.Sprintf(want, second); string(have) != want {
+			t.Errorf("\nhave:%s\nwant:%s", have, want)
+			return
+		}
+	}
+}
+
+func TestOtelFormatter_FormatZeroTime(t *testing.T) {
+	tests := []struct {
+		time time.Time
+		want string
+	}{
+		{time.Time{}, `"timeUnixNano":"0"`},
+		{time.Date(3000, time.January, 1, 0, 0, 0, 5, time.UTC), `"timeUnixNano":"32503680000000000005"`},
+	}
+	for _, v := range tests {
+		have, err := NewOtelFormatter().Format(&Entry{Time: v.time, Level: InfoLevel})
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if !strings.Contains(string(have), v.want) {
+			t.Errorf("have:%s, want contains:%s", have, v.want)
+			return
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"strings"
+
+	internalfields "github.com/chanxuehong/log/internal/fields"
+)
+
+// RecoverOption configures Recover, Go and RecoverHandler.
+type RecoverOption func(*recoverOptions)
+
+// WithRecoverLevel sets the level at which the panic is logged, the default is FatalLevel.
+// The panic logged at FatalLevel does not exit the program even if the Logger is created with WithFatalExit.
+func WithRecoverLevel(level Level) RecoverOption {
+	return func(o *recoverOptions) {
+		if !isValidLevel(level) {
+			return
+		}
+		o.level = level
+	}
+}
+
+// WithRepanic makes the panic continue (panic again with the same value) after it is logged.
+func WithRepanic(repanic bool) RecoverOption {
+	return func(o *recoverOptions) {
+		o.repanic = repanic
+	}
+}
+
+type recoverOptions struct {
+	level   Level
+	repanic bool
+}
+
+func newRecoverOptions(opts []RecoverOption) recoverOptions {
+	o := recoverOptions{
+		level: FatalLevel,
+	}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(&o)
+	}
+	return o
+}
+
+const (
+	fieldKeyPanic      = "panic"
+	fieldKeyPanicStack = "panic_stack"
+)
+
+// Recover recovers the panic and logs it by lg (the standard logger if lg is nil),
+// the panic value is written as the "panic" field and the stack trace as the "panic_stack" field,
+// the location of the entry is where the panic occurred.
+//
+// Recover must be called directly by defer, for example
+//
+//	defer log.Recover(lg)
+func Recover(lg Logger, opts ...RecoverOption) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	o := newRecoverOptions(opts)
+	logPanic(lg, r, o.level)
+	if o.repanic {
+		panic(r)
+	}
+}
+
+// Go runs fn in a new goroutine, the panic of fn is recovered and logged by the Logger from ctx
+// (the standard logger if there is none), see Recover.
+func Go(ctx context.Context, fn func(ctx context.Context), opts ...RecoverOption) {
+	lg, _ := FromContext(ctx)
+	go func() {
+		defer Recover(lg, opts...)
+		fn(ctx)
+	}()
+}
+
+// RecoverHandler returns a http.Handler which recovers the panic of next, logs it by the Logger from the request
+// (the standard logger if there is none) and replies with 500 Internal Server Error, see Recover.
+//
+// The http.ErrAbortHandler panic is not recovered, since it is used to abort the response.
+func RecoverHandler(next http.Handler, opts ...RecoverOption) http.Handler {
+	o := newRecoverOptions(opts)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			if r == http.ErrAbortHandler {
+				panic(r)
+			}
+			lg, _ := FromRequest(req)
+			logPanic(lg, r, o.level)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			if o.repanic {
+				panic(r)
+			}
+		}()
+		next.ServeHTTP(w, req)
+	})
+}
+
+// logPanic logs the panic value r, it must be called by the deferred function which recovers the panic.
+func logPanic(lg Logger, r interface{}, level Level) {
+	if lg == nil {
+		lg = _std
+	}
+	frames, depth := panicFrames()
+	fields := []interface{}{
+		fieldKeyPanic, r,
+		fieldKeyPanicStack, internalfields.FormatFrames(frames),
+	}
+	if l, ok := lg.(*logger); ok {
+		// the panic does not exit the program even if it is logged at FatalLevel, see WithFatalExit
+		l.output(depth, level, "panic recovered", fields, false)
+		return
+	}
+	lg.Output(depth, level, "panic recovered", fields...)
+}
+
+// panicFrames returns the frames of the current goroutine from the function which panicked,
+// and the depth of the function relative to the caller of panicFrames, which can be used as the calldepth of Output.
+func panicFrames() (frames []runtime.Frame, depth int) {
+	var pcs [maxStacktraceDepth]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames = internalfields.CallersFrames(pcs[:n])
+	for i, frame := range frames {
+		if frame.Function != "runtime.gopanic" {
+			continue
+		}
+		// the panic may be raised by the runtime, for example runtime.panicIndex
+		for i++; i < len(frames) && strings.HasPrefix(frames[i].Function, "runtime."); i++ {
+		}
+		if i < len(frames) {
+			return frames[i:], i
+		}
+		break
+	}
+	return frames, 0
+}
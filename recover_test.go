@@ -0,0 +1,226 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newRecoverTestLogger() (Logger, *bytes.Buffer) {
+	buffer := &bytes.Buffer{}
+	lg := New(
+		WithFormatter(JsonFormatter),
+		WithOutput(buffer),
+		WithTraceId("trace_id_123456789"),
+	)
+	return lg, buffer
+}
+
+func decodeRecoverTestEntry(t *testing.T, data []byte) map[string]interface{} {
+	var entry map[string]interface{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("failed to decode %q: %v", data, err)
+	}
+	return entry
+}
+
+func testRecoverPanic(lg Logger, opts ...RecoverOption) {
+	defer Recover(lg, opts...)
+	panic("panic_123456789")
+}
+
+func testRecoverRuntimePanic(lg Logger) {
+	defer Recover(lg)
+	var m map[string]int
+	m["key"] = 1 // assignment to entry in nil map
+}
+
+func TestRecover(t *testing.T) {
+	lg, buffer := newRecoverTestLogger()
+	testRecoverPanic(lg)
+
+	entry := decodeRecoverTestEntry(t, buffer.Bytes())
+	if have, want := entry["level"], "fatal"; have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+	if have, want := entry["request_id"], "trace_id_123456789"; have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+	if have, want := entry["panic"], "panic_123456789"; have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+	if location, _ := entry["location"].(string); !strings.HasPrefix(location, "log.testRecoverPanic(") {
+		t.Errorf("not expected location: %s", location)
+		return
+	}
+	stack, _ := entry["panic_stack"].(string)
+	frames := strings.Split(stack, "\n")
+	if len(frames) < 2 || !strings.HasPrefix(frames[0], "log.testRecoverPanic(") || !strings.HasPrefix(frames[1], "log.TestRecover(") {
+		t.Errorf("not expected panic_stack: %s", stack)
+		return
+	}
+
+	// runtime error
+	buffer.Reset()
+	testRecoverRuntimePanic(lg)
+	entry = decodeRecoverTestEntry(t, buffer.Bytes())
+	if have, want := entry["panic"], "assignment to entry in nil map"; have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+	if location, _ := entry["location"].(string); !strings.HasPrefix(location, "log.testRecoverRuntimePanic(") {
+		t.Errorf("not expected location: %s", location)
+		return
+	}
+}
+
+func TestRecover_Options(t *testing.T) {
+	lg, buffer := newRecoverTestLogger()
+
+	var repanicked interface{}
+	func() {
+		defer func() { repanicked = recover() }()
+		testRecoverPanic(lg, WithRecoverLevel(ErrorLevel), WithRepanic(true))
+	}()
+	if have, want := repanicked, "panic_123456789"; have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+	entry := decodeRecoverTestEntry(t, buffer.Bytes())
+	if have, want := entry["level"], "error"; have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+}
+
+// recoverTestWriter signals written after each Write, so the test can wait for the entry logged by
+// another goroutine.
+type recoverTestWriter struct {
+	bytes.Buffer
+	written chan struct{}
+}
+
+func (w *recoverTestWriter) Write(p []byte) (int, error) {
+	n, err := w.Buffer.Write(p)
+	w.written <- struct{}{}
+	return n, err
+}
+
+func TestGo(t *testing.T) {
+	buffer := &recoverTestWriter{written: make(chan struct{}, 1)}
+	lg := New(
+		WithFormatter(JsonFormatter),
+		WithOutput(buffer),
+	)
+	Go(NewContext(context.Background(), lg), func(ctx context.Context) {
+		panic("panic_123456789")
+	})
+	select {
+	case <-buffer.written:
+	case <-time.After(5 * time.Second):
+		t.Error("timeout")
+		return
+	}
+
+	entry := decodeRecoverTestEntry(t, buffer.Bytes())
+	if have, want := entry["panic"], "panic_123456789"; have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+	if location, _ := entry["location"].(string); !strings.HasPrefix(location, "log.TestGo.func1(") {
+		t.Errorf("not expected location: %s", location)
+		return
+	}
+}
+
+func TestRecoverHandler(t *testing.T) {
+	lg, buffer := newRecoverTestLogger()
+	handler := RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("panic_123456789")
+	}))
+
+	req := NewRequest(httptest.NewRequest(http.MethodGet, "/", nil), lg)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	if have, want := recorder.Code, http.StatusInternalServerError; have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+	entry := decodeRecoverTestEntry(t, buffer.Bytes())
+	if have, want := entry["panic"], "panic_123456789"; have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+
+	// http.ErrAbortHandler
+	handler = RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	var repanicked interface{}
+	func() {
+		defer func() { repanicked = recover() }()
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	if repanicked != http.ErrAbortHandler {
+		t.Errorf("have:%v, want:%v", repanicked, http.ErrAbortHandler)
+		return
+	}
+}
+
+func TestRecoverHandler_FatalExit(t *testing.T) {
+	codes, restore := replaceExitFunc()
+	defer restore()
+
+	var buffer bytes.Buffer
+	lg := New(
+		WithFormatter(JsonFormatter),
+		WithOutput(&buffer),
+		WithFatalExit(1),
+	)
+	handler := RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("panic_123456789")
+	}))
+	req := NewRequest(httptest.NewRequest(http.MethodGet, "/", nil), lg)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	if len(*codes) != 0 {
+		t.Errorf("ExitFunc called with %v, want not called", *codes)
+		return
+	}
+	if have, want := recorder.Code, http.StatusInternalServerError; have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+	entry := decodeRecoverTestEntry(t, buffer.Bytes())
+	if have, want := entry["level"], "fatal"; have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+	if location, _ := entry["location"].(string); !strings.HasPrefix(location, "log.TestRecoverHandler_FatalExit.func1(") {
+		t.Errorf("not expected location: %s", location)
+		return
+	}
+
+	// Recover does not exit either, while Fatal does
+	buffer.Reset()
+	testRecoverPanic(lg)
+	if len(*codes) != 0 {
+		t.Errorf("ExitFunc called with %v, want not called", *codes)
+		return
+	}
+	lg.Fatal("fatal")
+	if have := *codes; len(have) != 1 || have[0] != 1 {
+		t.Errorf("have:%v, want:[1]", have)
+		return
+	}
+}
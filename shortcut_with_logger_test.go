@@ -471,7 +471,7 @@ func TestOutputContext(t *testing.T) {
 		MustFromContext(testWithLoggerContext).SetOutput(ConcurrentWriter(&buf))
 		MustFromContext(testWithLoggerContext).SetFormatter(testJsonFormatter{})
 
-		OutputContext(testWithLoggerContext, 0, DebugLevel+1, "debug-msg", "field1-key", "field1-value", "field2-key", "field2-value")
+		OutputContext(testWithLoggerContext, 0, TraceLevel+1, "debug-msg", "field1-key", "field1-value", "field2-key", "field2-value")
 		data := buf.Bytes()
 		if len(data) != 0 {
 			t.Errorf("want empty, but now is: %s", data)
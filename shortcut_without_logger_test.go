@@ -471,7 +471,7 @@ func TestOutputContextWithoutLogger(t *testing.T) {
 		SetOutput(ConcurrentWriter(&buf))
 		SetFormatter(testJsonFormatter{})
 
-		OutputContext(testWithoutLoggerContext, 0, DebugLevel+1, "debug-msg", "field1-key", "field1-value", "field2-key", "field2-value")
+		OutputContext(testWithoutLoggerContext, 0, TraceLevel+1, "debug-msg", "field1-key", "field1-value", "field2-key", "field2-value")
 		data := buf.Bytes()
 		if len(data) != 0 {
 			t.Errorf("want empty, but now is: %s", data)
@@ -7,31 +7,31 @@ var _std = _New(nil)
 // Fatal logs a message at FatalLevel on the standard logger.
 // For more information see the Logger interface.
 func Fatal(msg string, fields ...interface{}) {
-	_std.output(1, FatalLevel, msg, fields)
+	_std.output(1, FatalLevel, msg, fields, true)
 }
 
 // Error logs a message at ErrorLevel on the standard logger.
 // For more information see the Logger interface.
 func Error(msg string, fields ...interface{}) {
-	_std.output(1, ErrorLevel, msg, fields)
+	_std.output(1, ErrorLevel, msg, fields, false)
 }
 
 // Warn logs a message at WarnLevel on the standard logger.
 // For more information see the Logger interface.
 func Warn(msg string, fields ...interface{}) {
-	_std.output(1, WarnLevel, msg, fields)
+	_std.output(1, WarnLevel, msg, fields, false)
 }
 
 // Info logs a message at InfoLevel on the standard logger.
 // For more information see the Logger interface.
 func Info(msg string, fields ...interface{}) {
-	_std.output(1, InfoLevel, msg, fields)
+	_std.output(1, InfoLevel, msg, fields, false)
 }
 
 // Debug logs a message at DebugLevel on the standard logger.
 // For more information see the Logger interface.
 func Debug(msg string, fields ...interface{}) {
-	_std.output(1, DebugLevel, msg, fields)
+	_std.output(1, DebugLevel, msg, fields, false)
 }
 
 // Output logs a message at specified level on the standard logger.
@@ -72,3 +72,14 @@ func SetLevel(level Level) error {
 func SetLevelString(str string) error {
 	return _std.SetLevelString(str)
 }
+
+// SetOptions replaces the standard logger options, opts are applied after the default options
+// set by SetDefaultOptions, the same as New. The standard logger is created before SetDefaultOptions
+// can be called, so SetOptions is the way to give it the options such as WithFatalExit,
+// WithErrorHandler, WithFallbackOutput and WithStacktraceLevel.
+func SetOptions(opts ...Option) {
+	_std.mu.Lock()
+	defer _std.mu.Unlock()
+
+	_std.setOptions(newOptions(opts))
+}
@@ -168,7 +168,7 @@ func (testJsonFormatter) Format(entry *Entry) ([]byte, error) {
 
 	// ignored entry.Time
 	m := make(map[string]interface{})
-	fixFieldsConflictAndHandleErrorFields(entry.Fields)
+	fixFieldsConflictAndHandleErrorFields(entry.Fields, stdFieldKeys)
 	m[fieldKeyTraceId] = entry.TraceId
 	m[fieldKeyLevel] = entry.Level.String()
 	m[fieldKeyMessage] = entry.Message
@@ -470,7 +470,7 @@ func TestOutput(t *testing.T) {
 		SetOutput(ConcurrentWriter(&buf))
 		SetFormatter(testJsonFormatter{})
 
-		Output(0, DebugLevel+1, "debug-msg", "field1-key", "field1-value", "field2-key", "field2-value")
+		Output(0, TraceLevel+1, "debug-msg", "field1-key", "field1-value", "field2-key", "field2-value")
 		data := buf.Bytes()
 		if len(data) != 0 {
 			t.Errorf("want empty, but now is: %s", data)
@@ -671,6 +671,47 @@ func TestSetOutput(t *testing.T) {
 	}
 }
 
+func TestSetOptions(t *testing.T) {
+	codes, restore := replaceExitFunc()
+	defer restore()
+	defer SetOptions()
+
+	var buf bytes.Buffer
+	output := ConcurrentWriter(&buf)
+	SetOptions(WithOutput(output), WithLevel(WarnLevel), WithFatalExit(3))
+
+	opts := _std.getOptions()
+	if have, want := opts.output, output; have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+	if have, want := opts.level, WarnLevel; have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+	if have, want := opts.formatter, TextFormatter; have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+
+	Fatal("fatal_123456789")
+	if !strings.Contains(buf.String(), "fatal_123456789") {
+		t.Errorf("have:%s, want contains:%s", buf.String(), "fatal_123456789")
+		return
+	}
+	if have, want := *codes, []int{3}; !reflect.DeepEqual(have, want) {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+
+	// reset to the default
+	SetOptions()
+	if have, want := *_std.getOptions(), *newOptions(nil); have != want {
+		t.Errorf("have:%v, want:%v", have, want)
+		return
+	}
+}
+
 func TestSetLevel(t *testing.T) {
 	defer setStdOptionsToDefault()
 
@@ -745,7 +786,7 @@ func TestSetLevel(t *testing.T) {
 		}
 	}
 	{
-		SetLevel(DebugLevel + 1)
+		SetLevel(TraceLevel + 1)
 
 		have := _std.getOptions().level
 		want := InfoLevel
@@ -840,7 +881,7 @@ func TestSetLevelString(t *testing.T) {
 		}
 	}
 	{
-		SetLevelString("trace")
+		SetLevelString("verbose")
 
 		have := _std.getOptions().level
 		want := InfoLevel
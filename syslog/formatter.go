@@ -0,0 +1,295 @@
+// Package syslog provides a log.Formatter which formats the log.Entry as a RFC 5424 syslog message,
+// and a Writer which sends the messages to the syslog daemon over unix socket, UDP or TCP.
+package syslog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chanxuehong/log"
+	internalfields "github.com/chanxuehong/log/internal/fields"
+)
+
+// Facility is the syslog facility, see RFC 5424 section 6.2.1.
+type Facility int
+
+const (
+	Kern Facility = iota
+	User
+	Mail
+	Daemon
+	Auth
+	Syslog
+	Lpr
+	News
+	Uucp
+	Cron
+	Authpriv
+	Ftp
+	Ntp
+	Audit
+	Alert
+	Clock
+	Local0
+	Local1
+	Local2
+	Local3
+	Local4
+	Local5
+	Local6
+	Local7
+)
+
+// DefaultStructuredDataId is the default SD-ID of the STRUCTURED-DATA element which carries the fields,
+// 32473 is the private enterprise number reserved for documentation (RFC 5612).
+const DefaultStructuredDataId = "fields@32473"
+
+const (
+	nilValue = "-"
+
+	timestampLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+	maxHostnameLength = 255
+	maxAppNameLength  = 48
+	maxProcIdLength   = 128
+	maxMsgIdLength    = 32
+	maxSdNameLength   = 32
+)
+
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// paramValueEncoder returns the PARAM-VALUE of the field value, time.Time is written the same as TIMESTAMP.
+var paramValueEncoder = internalfields.TextEncoder{
+	FormatTime: func(t time.Time) string {
+		return t.UTC().Format(timestampLayout)
+	},
+}
+
+// reservedKeys are the PARAM-NAMEs written by the Formatter besides the fields.
+var reservedKeys = []string{
+	"request_id",
+	"location",
+}
+
+type Option func(*options)
+
+// WithFacility sets the facility, the default is User.
+func WithFacility(facility Facility) Option {
+	return func(o *options) {
+		if facility < Kern || facility > Local7 {
+			return
+		}
+		o.facility = facility
+	}
+}
+
+// WithHostname sets the HOSTNAME, the default is os.Hostname().
+func WithHostname(hostname string) Option {
+	return func(o *options) {
+		o.hostname = hostname
+	}
+}
+
+// WithAppName sets the APP-NAME, the default is the base name of os.Args[0].
+func WithAppName(appName string) Option {
+	return func(o *options) {
+		o.appName = appName
+	}
+}
+
+// WithMsgId sets the MSGID, the default is NILVALUE.
+func WithMsgId(msgId string) Option {
+	return func(o *options) {
+		o.msgId = msgId
+	}
+}
+
+// WithStructuredDataId sets the SD-ID of the STRUCTURED-DATA element which carries the fields,
+// the default is DefaultStructuredDataId.
+func WithStructuredDataId(id string) Option {
+	return func(o *options) {
+		if id == "" {
+			return
+		}
+		o.structuredDataId = id
+	}
+}
+
+type options struct {
+	facility         Facility
+	hostname         string
+	appName          string
+	msgId            string
+	structuredDataId string
+}
+
+// NewFormatter returns a log.Formatter which formats the log.Entry as a RFC 5424 syslog message.
+//
+// The log.Level is mapped to the severity (see log.Level.SyslogSeverity), the Entry.TraceId, Entry.Location
+// and Entry.Fields are written as the SD-PARAMs of one STRUCTURED-DATA element, and the Entry.Message is written as MSG.
+// The field values are written the same as log.TextFormatter, except that time.Time is written the same as TIMESTAMP.
+func NewFormatter(opts ...Option) log.Formatter {
+	o := options{
+		facility:         User,
+		structuredDataId: DefaultStructuredDataId,
+	}
+	o.hostname, _ = os.Hostname()
+	if len(os.Args) > 0 {
+		o.appName = filepath.Base(os.Args[0])
+	}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(&o)
+	}
+	return &formatter{
+		facility:         o.facility,
+		hostname:         headerField(o.hostname, maxHostnameLength),
+		appName:          headerField(o.appName, maxAppNameLength),
+		procId:           headerField(strconv.Itoa(os.Getpid()), maxProcIdLength),
+		msgId:            headerField(o.msgId, maxMsgIdLength),
+		structuredDataId: sdName(o.structuredDataId, maxSdNameLength),
+	}
+}
+
+type formatter struct {
+	facility         Facility
+	hostname         string
+	appName          string
+	procId           string
+	msgId            string
+	structuredDataId string
+}
+
+func (f *formatter) Format(entry *log.Entry) ([]byte, error) {
+	var buffer *bytes.Buffer
+	if entry.Buffer != nil {
+		buffer = entry.Buffer
+	} else {
+		buffer = bytes.NewBuffer(make([]byte, 0, 16<<10))
+	}
+	var scratch [64]byte
+
+	// HEADER
+	buffer.WriteByte('<')
+	buffer.Write(strconv.AppendInt(scratch[:0], int64(f.facility)*8+int64(entry.Level.SyslogSeverity()), 10))
+	buffer.WriteString(">1 ")
+	buffer.Write(entry.Time.UTC().AppendFormat(scratch[:0], timestampLayout))
+	buffer.WriteByte(' ')
+	buffer.WriteString(f.hostname)
+	buffer.WriteByte(' ')
+	buffer.WriteString(f.appName)
+	buffer.WriteByte(' ')
+	buffer.WriteString(f.procId)
+	buffer.WriteByte(' ')
+	buffer.WriteString(f.msgId)
+	buffer.WriteByte(' ')
+
+	// STRUCTURED-DATA
+	fields := entry.Fields
+	internalfields.Normalize(fields, reservedKeys)
+	if entry.TraceId == "" && entry.Location == "" && len(fields) == 0 {
+		buffer.WriteString(nilValue)
+	} else {
+		buffer.WriteByte('[')
+		buffer.WriteString(f.structuredDataId)
+		if entry.TraceId != "" {
+			appendParam(buffer, "request_id", entry.TraceId)
+		}
+		if entry.Location != "" {
+			appendParam(buffer, "location", entry.Location)
+		}
+		if len(fields) > 0 {
+			keys := make([]string, 0, len(fields))
+			for k := range fields {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				appendParam(buffer, sdName(k, maxSdNameLength), paramValueEncoder.Text(fields[k]))
+			}
+		}
+		buffer.WriteByte(']')
+	}
+
+	// MSG
+	if entry.Message != "" {
+		buffer.WriteByte(' ')
+		if !isASCII(entry.Message) {
+			buffer.Write(utf8BOM)
+		}
+		buffer.WriteString(entry.Message)
+	}
+	buffer.WriteByte('\n')
+	return buffer.Bytes(), nil
+}
+
+// appendParam writes the SD-PARAM to b, the '"', '\' and ']' in value are escaped.
+func appendParam(b *bytes.Buffer, name, value string) {
+	b.WriteByte(' ')
+	b.WriteString(name)
+	b.WriteString(`="`)
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '"', '\\', ']':
+			b.WriteString(value[start:i])
+			b.WriteByte('\\')
+			b.WriteByte(c)
+			start = i + 1
+		}
+	}
+	b.WriteString(value[start:])
+	b.WriteByte('"')
+}
+
+// headerField returns the HEADER field which consists of PRINTUSASCII and is at most maxLength long,
+// the other characters are replaced with '_', and NILVALUE is returned for the empty str.
+func headerField(str string, maxLength int) string {
+	if str == "" {
+		return nilValue
+	}
+	if len(str) > maxLength {
+		str = str[:maxLength]
+	}
+	b := []byte(str)
+	for i, c := range b {
+		if c < 33 || c > 126 {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// sdName returns the SD-NAME which consists of PRINTUSASCII except '=', SP, ']' and '"',
+// and is at most maxLength long, the other characters are replaced with '_'.
+func sdName(str string, maxLength int) string {
+	if str == "" {
+		return "_"
+	}
+	if len(str) > maxLength {
+		str = str[:maxLength]
+	}
+	b := []byte(str)
+	for i, c := range b {
+		if c < 33 || c > 126 || c == '=' || c == ']' || c == '"' {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+func isASCII(str string) bool {
+	for i := 0; i < len(str); i++ {
+		if str[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
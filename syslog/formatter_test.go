@@ -0,0 +1,67 @@
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chanxuehong/log"
+)
+
+type testContextError struct{}
+
+func (testContextError) Error() string { return "context_error" }
+
+func (testContextError) ErrorContext() string { return "context]\"\\" }
+
+func TestFormatter_Format(t *testing.T) {
+	f := NewFormatter(
+		WithFacility(Local0),
+		WithHostname("example.com"),
+		WithAppName("my app"),
+		WithMsgId("ID47"),
+	)
+	procId := f.(*formatter).procId
+
+	entry := &log.Entry{
+		Location: "main.main(main.go:10)",
+		Time:     time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+		Level:    log.WarnLevel,
+		TraceId:  "trace_id_123456789",
+		Message:  "message 中文",
+		Fields: map[string]interface{}{
+			"key1":       "fields_value1",
+			"key 2=":     2,
+			"error":      testContextError{},
+			"request_id": "request_id",
+			"created":    time.Date(2018, time.May, 20, 16, 20, 30, 0, time.FixedZone("UTC+8", 8*60*60)),
+		},
+	}
+	have, err := f.Format(entry)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	want := "<132>1 2018-05-20T08:20:30.666777Z example.com my_app " + procId + " ID47 " +
+		`[fields@32473 request_id="trace_id_123456789" location="main.main(main.go:10)" created="2018-05-20T08:20:30.000000Z" ` +
+		`error="context_error" error_context="context\]\"\\" field.request_id="request_id" key_2_="2" key1="fields_value1"]` +
+		" \xef\xbb\xbfmessage 中文\n"
+	if string(have) != want {
+		t.Errorf("\nhave:%s\nwant:%s", have, want)
+		return
+	}
+
+	// without STRUCTURED-DATA and MSG
+	have, err = NewFormatter(WithHostname(""), WithAppName("app")).Format(&log.Entry{
+		Time:  time.Date(2018, time.May, 20, 8, 20, 30, 0, time.UTC),
+		Level: log.ErrorLevel,
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	want = "<11>1 2018-05-20T08:20:30.000000Z - app " + procId + " - -\n"
+	if string(have) != want {
+		t.Errorf("\nhave:%s\nwant:%s", have, want)
+		return
+	}
+}
@@ -0,0 +1,168 @@
+package syslog
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DialTimeout is the timeout of connecting to the syslog daemon.
+const DialTimeout = 5 * time.Second
+
+var localSyslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+var (
+	_ErrWriterClosed     = errors.New("syslog: the Writer is closed")
+	_ErrLocalUnavailable = errors.New("syslog: the local syslog daemon is unavailable")
+)
+
+// Writer is a thread-safe io.Writer which sends the syslog messages (see NewFormatter) to the syslog daemon.
+//
+// Over "unixgram" and "udp", each message is sent as one datagram.
+// Over "unix" and "tcp", the messages are framed by octet counting (RFC 6587 section 3.4.1).
+// If sending fails before any byte of the message is sent, the connection is re-established and the message
+// is sent again once. If a part of the message has been sent, only the remainder is sent again on the same
+// connection if the error is a timeout, otherwise the error is returned, since sending the whole message on
+// a new connection would duplicate the sent part.
+type Writer struct {
+	network string
+	address string
+
+	mu     sync.Mutex // protects the following fields
+	conn   net.Conn
+	closed bool
+}
+
+// Dial returns a Writer which sends the syslog messages to address over network.
+//
+// If network is empty, the Writer connects to the local syslog daemon, that is the first available one
+// of /dev/log, /var/run/syslog and /var/run/log, and address is ignored.
+// Otherwise network must be "unixgram", "unix", "udp", "udp4", "udp6", "tcp", "tcp4" or "tcp6".
+func Dial(network, address string) (*Writer, error) {
+	switch network {
+	case "", "unixgram", "unix", "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6":
+	default:
+		return nil, errors.New("syslog: unsupported network: " + network)
+	}
+	w := &Writer{
+		network: network,
+		address: address,
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// connect connects to the syslog daemon, w.mu must be held if w is shared.
+func (w *Writer) connect() error {
+	if w.network != "" {
+		conn, err := net.DialTimeout(w.network, w.address, DialTimeout)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+		return nil
+	}
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range localSyslogPaths {
+			conn, err := net.DialTimeout(network, path, DialTimeout)
+			if err != nil {
+				continue
+			}
+			w.conn = conn
+			return nil
+		}
+	}
+	return _ErrLocalUnavailable
+}
+
+// isStream reports whether the connection is stream-oriented, which needs octet counting.
+func (w *Writer) isStream() bool {
+	if w.conn == nil {
+		return false
+	}
+	switch w.conn.LocalAddr().Network() {
+	case "unix", "tcp", "tcp4", "tcp6":
+		return true
+	default:
+		return false
+	}
+}
+
+// Write sends p as a syslog message, the trailing newline of p is removed.
+func (w *Writer) Write(p []byte) (n int, err error) {
+	n = len(p)
+	if i := len(p) - 1; i >= 0 && p[i] == '\n' {
+		p = p[:i]
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, _ErrWriterClosed
+	}
+	if w.conn != nil {
+		written, err := w.write(p)
+		if err == nil {
+			return n, nil
+		}
+		w.conn.Close()
+		w.conn = nil
+		if written > 0 {
+			return 0, err
+		}
+	}
+	// re-establish the connection and send again
+	if err = w.connect(); err != nil {
+		return 0, err
+	}
+	if _, err = w.write(p); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		return 0, err
+	}
+	return n, nil
+}
+
+// write sends p over the connection, it returns the number of bytes sent, including the octet counting.
+func (w *Writer) write(p []byte) (written int, err error) {
+	if !w.isStream() {
+		return w.conn.Write(p)
+	}
+	message := make([]byte, 0, len(p)+12)
+	message = strconv.AppendInt(message, int64(len(p)), 10)
+	message = append(message, ' ')
+	message = append(message, p...)
+	for {
+		n, err := w.conn.Write(message[written:])
+		written += n
+		if err == nil || written >= len(message) {
+			return written, nil
+		}
+		// send the remainder after the partial write is timed out, the sent part must not be sent again
+		if ne, ok := err.(net.Error); !ok || !ne.Timeout() || n == 0 {
+			return written, err
+		}
+	}
+}
+
+// Close closes the connection, the subsequent Write returns an error.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
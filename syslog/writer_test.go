@@ -0,0 +1,193 @@
+package syslog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriter_Datagram(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syslog")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	for _, v := range []struct {
+		network string
+		address string
+	}{
+		{"unixgram", filepath.Join(dir, "log.sock")},
+		{"udp", "127.0.0.1:0"},
+	} {
+		conn, err := net.ListenPacket(v.network, v.address)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		defer conn.Close()
+
+		w, err := Dial(v.network, conn.LocalAddr().String())
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		defer w.Close()
+
+		if _, err = w.Write([]byte("<14>1 - - - - - - message\n")); err != nil {
+			t.Error(err.Error())
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		packet := make([]byte, 1024)
+		n, _, err := conn.ReadFrom(packet)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if have, want := string(packet[:n]), "<14>1 - - - - - - message"; have != want {
+			t.Errorf("network:%s, have:%q, want:%q", v.network, have, want)
+			return
+		}
+	}
+}
+
+func TestWriter_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer ln.Close()
+
+	// the server reads one message per connection and then closes it, so the Writer must reconnect.
+	messages := make(chan string, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			length, err := r.ReadString(' ')
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			n, _ := strconv.Atoi(strings.TrimSuffix(length, " "))
+			message := make([]byte, n)
+			if _, err = io.ReadFull(r, message); err == nil {
+				messages <- string(message)
+			}
+			conn.Close()
+		}
+	}()
+
+	w, err := Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer w.Close()
+
+	for i := 0; i < 2; i++ {
+		want := "<14>1 - - - - - - message" + strconv.Itoa(i)
+		// the peer close is detected lazily, so retry until the message is received.
+		deadline := time.Now().Add(5 * time.Second)
+	RECEIVE:
+		for {
+			if _, err = w.Write([]byte(want + "\n")); err != nil {
+				t.Error(err.Error())
+				return
+			}
+			select {
+			case have := <-messages:
+				if have != want {
+					t.Errorf("have:%q, want:%q", have, want)
+					return
+				}
+				break RECEIVE
+			case <-time.After(100 * time.Millisecond):
+				if time.Now().After(deadline) {
+					t.Error("timeout")
+					return
+				}
+			}
+		}
+	}
+
+	w.Close()
+	if _, err = w.Write([]byte("x")); err != _ErrWriterClosed {
+		t.Errorf("have:%v, want:%v", err, _ErrWriterClosed)
+		return
+	}
+}
+
+// partialConn is the TCP net.Conn which writes at most limit bytes per Write, the short Write returns err.
+type partialConn struct {
+	net.Conn
+	limit int
+	err   error
+	data  []byte
+}
+
+func (c *partialConn) Write(p []byte) (int, error) {
+	if len(p) <= c.limit {
+		c.data = append(c.data, p...)
+		return len(p), nil
+	}
+	c.data = append(c.data, p[:c.limit]...)
+	return c.limit, c.err
+}
+
+func (c *partialConn) LocalAddr() net.Addr { return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)} }
+func (c *partialConn) Close() error        { return nil }
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestWriter_PartialWrite(t *testing.T) {
+	// the remainder is sent after the timeout
+	{
+		conn := &partialConn{limit: 10, err: timeoutError{}}
+		w := &Writer{network: "tcp", address: "127.0.0.1:1", conn: conn}
+		if _, err := w.Write([]byte("<14>1 - - - - - - message\n")); err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if have, want := string(conn.data), "25 <14>1 - - - - - - message"; have != want {
+			t.Errorf("have:%q, want:%q", have, want)
+			return
+		}
+	}
+	// the message is not sent again on a new connection after a part of it is sent
+	{
+		errBroken := errors.New("broken pipe")
+		conn := &partialConn{limit: 10, err: errBroken}
+		w := &Writer{network: "tcp", address: "127.0.0.1:1", conn: conn}
+		if _, err := w.Write([]byte("<14>1 - - - - - - message\n")); err != errBroken {
+			t.Errorf("have:%v, want:%v", err, errBroken)
+			return
+		}
+		if have, want := string(conn.data), "25 <14>1 -"; have != want {
+			t.Errorf("have:%q, want:%q", have, want)
+			return
+		}
+		if w.conn != nil {
+			t.Error("want the connection closed")
+			return
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package log
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// TemplateField is a field of Entry.Fields, see the "fields" function of TemplateFormatter.
+type TemplateField struct {
+	Key   string
+	Value interface{}
+}
+
+// NewTemplateFormatter returns a Formatter which formats the Entry with the text/template text.
+// The template is compiled once, and it is executed with the *Entry, so it has access to .Time, .Level,
+// .TraceId, .Location, .Message and .Fields. A newline is appended if the output does not end with it.
+//
+// Besides the predefined functions of text/template, the following functions are available:
+//
+//  formatTime TIME                    formats TIME by the WithTimeLocation and WithTimeEncoding options
+//  timeFormat TIME LAYOUT             formats TIME in the WithTimeLocation location with LAYOUT
+//  timeIn TIME ZONE LAYOUT            formats TIME in the time zone named ZONE (for example "UTC") with LAYOUT
+//  pad WIDTH VALUE                    pads the string form of VALUE with spaces on the right to WIDTH
+//  padLeft WIDTH VALUE                pads the string form of VALUE with spaces on the left to WIDTH
+//  upper VALUE, lower VALUE           converts the string form of VALUE to upper or lower case
+//  json VALUE                         the JSON encoding of VALUE, for example a quoted string, a value which
+//                                     can not be encoded is written as the "!ERROR: " string like JsonFormatter
+//  value VALUE                        the string form of VALUE, the same as TextFormatter writes
+//  color NAME VALUE                   colors the string form of VALUE, NAME is one of red, green, yellow,
+//                                     blue, magenta, cyan and faint, see WithColor
+//  levelColor LEVEL VALUE             colors the string form of VALUE by LEVEL, the same as ConsoleFormatter
+//  fields FIELDS                      the []TemplateField of FIELDS sorted by key
+//
+// For example:
+//
+//  {{formatTime .Time}} [{{upper .Level | pad 7}}] {{.Message}}{{range fields .Fields}} {{.Key}}={{json .Value}}{{end}}
+func NewTemplateFormatter(text string, opts ...FormatterOption) (Formatter, error) {
+	o := newFormatterOptions(opts)
+	f := &templateFormatter{
+		opts: o,
+		auto: o.autoColor(),
+	}
+	tmpl, err := template.New("log").Funcs(f.funcMap(o.useColor())).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	f.tmpl = tmpl
+	if f.auto {
+		// the colored template is used when the output of the Logger is a terminal, see colorFormatter
+		if f.colorTmpl, err = tmpl.Clone(); err != nil {
+			return nil, err
+		}
+		f.colorTmpl.Funcs(f.funcMap(true))
+	}
+	return f, nil
+}
+
+// MustNewTemplateFormatter is like NewTemplateFormatter but panics if the template text cannot be parsed.
+func MustNewTemplateFormatter(text string, opts ...FormatterOption) Formatter {
+	f, err := NewTemplateFormatter(text, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+type templateFormatter struct {
+	opts      *formatterOptions
+	auto      bool               // the color is decided by the output, see colorFormatter
+	tmpl      *template.Template // writes the color only if it is enabled by WithColor
+	colorTmpl *template.Template // writes the color, it is nil unless auto is true
+}
+
+func (f *templateFormatter) Format(entry *Entry) ([]byte, error) {
+	return f.format(f.tmpl, entry)
+}
+
+func (f *templateFormatter) formatColor(entry *Entry, color bool) ([]byte, error) {
+	if f.auto && color {
+		return f.format(f.colorTmpl, entry)
+	}
+	return f.format(f.tmpl, entry)
+}
+
+func (f *templateFormatter) format(tmpl *template.Template, entry *Entry) ([]byte, error) {
+	var buffer *bytes.Buffer
+	if entry.Buffer != nil {
+		buffer = entry.Buffer
+	} else {
+		buffer = bytes.NewBuffer(make([]byte, 0, 16<<10))
+	}
+	if fields := entry.Fields; len(fields) > 0 {
+		fixFieldsConflictAndHandleErrorFields(fields, nil)
+	}
+	origin := buffer.Len()
+	if err := tmpl.Execute(buffer, entry); err != nil {
+		buffer.Truncate(origin)
+		return nil, err
+	}
+	if data := buffer.Bytes(); len(data) == origin || data[len(data)-1] != '\n' {
+		buffer.WriteByte('\n')
+	}
+	return buffer.Bytes(), nil
+}
+
+// funcMap returns the functions of the template, color reports whether the color functions write the color.
+func (f *templateFormatter) funcMap(color bool) template.FuncMap {
+	return template.FuncMap{
+		"formatTime": f.opts.formatTime,
+		"timeFormat": func(t time.Time, layout string) string {
+			return t.In(f.opts.timeLocation).Format(layout)
+		},
+		"timeIn": func(t time.Time, zone, layout string) (string, error) {
+			loc, err := loadLocation(zone)
+			if err != nil {
+				return "", err
+			}
+			return t.In(loc).Format(layout), nil
+		},
+		"pad": func(width int, value interface{}) string {
+			return padRight(templateString(value), width)
+		},
+		"padLeft": func(width int, value interface{}) string {
+			return padLeft(templateString(value), width)
+		},
+		"upper": func(value interface{}) string {
+			return strings.ToUpper(templateString(value))
+		},
+		"lower": func(value interface{}) string {
+			return strings.ToLower(templateString(value))
+		},
+		"json": func(value interface{}) string {
+			var buffer bytes.Buffer
+			appendJSONFieldValue(&buffer, value)
+			return buffer.String()
+		},
+		"value": templateString,
+		"color": func(name string, value interface{}) string {
+			return colored(color, templateColors[name], templateString(value))
+		},
+		"levelColor": func(level Level, value interface{}) string {
+			return colored(color, levelColor(level), templateString(value))
+		},
+		"fields": templateFields,
+	}
+}
+
+// colored wraps str in the ANSI color if enabled is true.
+func colored(enabled bool, color, str string) string {
+	if !enabled || color == "" {
+		return str
+	}
+	return color + str + ansiReset
+}
+
+var templateColors = map[string]string{
+	"red":     ansiRed,
+	"green":   ansiGreen,
+	"yellow":  ansiYellow,
+	"blue":    ansiBlue,
+	"magenta": ansiMagenta,
+	"cyan":    ansiCyan,
+	"faint":   ansiFaint,
+}
+
+func templateString(value interface{}) string {
+	if level, ok := value.(Level); ok {
+		return level.String()
+	}
+	return textValue(value)
+}
+
+func templateFields(fields map[string]interface{}) []TemplateField {
+	if len(fields) == 0 {
+		return nil
+	}
+	result := make([]TemplateField, 0, len(fields))
+	for k, v := range fields {
+		result = append(result, TemplateField{Key: k, Value: v})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+func padLeft(str string, width int) string {
+	padded := padRight(str, width)
+	if len(padded) == len(str) {
+		return str
+	}
+	return padded[len(str):] + str
+}
+
+var _locationCache sync.Map // map[string]*time.Location
+
+func loadLocation(name string) (*time.Location, error) {
+	if v, ok := _locationCache.Load(name); ok {
+		return v.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	_locationCache.Store(name, loc)
+	return loc, nil
+}
@@ -0,0 +1,131 @@
+package log
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTemplateFormatter_Format(t *testing.T) {
+	newEntry := func() *Entry {
+		return &Entry{
+			Location: "function(file:line)",
+			Time:     time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+			Level:    WarnLevel,
+			TraceId:  "trace_id_123456789",
+			Message:  "message_123456789",
+			Fields: map[string]interface{}{
+				"key2": "a \"b\"",
+				"key1": 1,
+				"key3": testContextError1{}, // error with ErrorContext
+			},
+			Buffer: nil,
+		}
+	}
+	tests := []struct {
+		text string
+		opts []FormatterOption
+		want string
+	}{
+		{
+			`{{formatTime .Time}} [{{upper .Level | pad 7}}] {{.Message}}{{range fields .Fields}} {{.Key}}={{json .Value}}{{end}}`,
+			nil,
+			`2018-05-20 16:20:30.666 [WARNING] message_123456789 key1=1 key2="a \"b\"" ` +
+				`key3="context_error1_error_123456789" key3_context="context_error1_context_123456789"` + "\n",
+		},
+		{
+			`{{timeFormat .Time "15:04:05"}} {{timeIn .Time "UTC" "15:04:05Z07:00"}} {{padLeft 6 .Level}}|{{.TraceId}}|{{.Location}}` + "\n",
+			nil,
+			"16:20:30 08:20:30Z warning|trace_id_123456789|function(file:line)\n",
+		},
+		{
+			`{{formatTime .Time}} {{levelColor .Level .Level}} {{.Message | color "cyan"}} {{value .Fields.key1}}`,
+			[]FormatterOption{WithColor(true), WithTimeLocation(time.UTC), WithTimeEncoding(TimeEncodingRFC3339)},
+			"2018-05-20T08:20:30Z \x1b[33mwarning\x1b[0m \x1b[36mmessage_123456789\x1b[0m 1\n",
+		},
+	}
+	for _, v := range tests {
+		formatter, err := NewTemplateFormatter(v.text, v.opts...)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		have, err := formatter.Format(newEntry())
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if string(have) != v.want {
+			t.Errorf("\nhave:%q\nwant:%q", have, v.want)
+			return
+		}
+	}
+
+	// invalid template
+	if _, err := NewTemplateFormatter(`{{.Message`); err == nil {
+		t.Error("want error")
+		return
+	}
+	// execution error
+	var buffer bytes.Buffer
+	buffer.WriteString("prefix")
+	entry := newEntry()
+	entry.Buffer = &buffer
+	if _, err := MustNewTemplateFormatter(`{{timeIn .Time "Invalid/Zone" "15:04"}}`).Format(entry); err == nil {
+		t.Error("want error")
+		return
+	}
+	if buffer.String() != "prefix" {
+		t.Errorf("have:%s, want:%s", buffer.String(), "prefix")
+		return
+	}
+}
+
+func TestTemplateFormatter_FormatBadValue(t *testing.T) {
+	entry := &Entry{
+		Time:    time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+		Level:   InfoLevel,
+		Message: "message_123456789",
+		Fields: map[string]interface{}{
+			"nan":   math.NaN(),
+			"panic": testPanicMarshaler{},
+		},
+	}
+	have, err := MustNewTemplateFormatter(`{{.Message}}{{range fields .Fields}} {{.Key}}={{json .Value}}{{end}}`).Format(entry)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	want := `message_123456789 nan="!ERROR: json: unsupported value: NaN" panic="!ERROR: panic: marshal_panic_123456789"` + "\n"
+	if string(have) != want {
+		t.Errorf("\nhave:%q\nwant:%q", have, want)
+		return
+	}
+}
+
+func BenchmarkTemplateFormatter(b *testing.B) {
+	formatter := MustNewTemplateFormatter(`time={{formatTime .Time}}, level={{.Level}}, request_id={{.TraceId}}, ` +
+		`location={{.Location}}, msg={{.Message}}{{range fields .Fields}}, {{.Key}}={{value .Value}}{{end}}`)
+	var buffer bytes.Buffer
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buffer.Reset()
+		if _, err := formatter.Format(newBenchmarkEntry(&buffer)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTextFormatter(b *testing.B) {
+	var buffer bytes.Buffer
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buffer.Reset()
+		if _, err := TextFormatter.Format(newBenchmarkEntry(&buffer)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -2,14 +2,18 @@ package log
 
 import (
 	"bytes"
-	"encoding/json"
-	"fmt"
-	"sort"
 )
 
-var TextFormatter Formatter = textFormatter{}
+var TextFormatter Formatter = NewTextFormatter()
 
-type textFormatter struct{}
+// NewTextFormatter returns a Formatter which formats the Entry as a text line of comma-separated key=value pairs.
+func NewTextFormatter(opts ...FormatterOption) Formatter {
+	return textFormatter{opts: newFormatterOptions(opts)}
+}
+
+type textFormatter struct {
+	opts *formatterOptions
+}
 
 func (f textFormatter) Format(entry *Entry) ([]byte, error) {
 	var buffer *bytes.Buffer
@@ -18,23 +22,28 @@ func (f textFormatter) Format(entry *Entry) ([]byte, error) {
 	} else {
 		buffer = bytes.NewBuffer(make([]byte, 0, 16<<10))
 	}
-	f.appendKeyValue(buffer, fieldKeyTime, FormatTime(entry.Time.In(_beijingLocation)))
-	f.appendKeyValue(buffer, fieldKeyLevel, entry.Level.String())
-	f.appendKeyValue(buffer, fieldKeyTraceId, entry.TraceId)
-	f.appendKeyValue(buffer, fieldKeyLocation, entry.Location)
-	f.appendKeyValue(buffer, fieldKeyMessage, entry.Message)
+	opts := f.opts
+	if opts.hasTime() {
+		f.appendKeyValue(buffer, opts.keys.Time, opts.formatTime(entry.Time))
+	}
+	if opts.hasLevel() {
+		f.appendKeyValue(buffer, opts.keys.Level, entry.Level.String())
+	}
+	if opts.hasTraceId(entry.TraceId) {
+		f.appendKeyValue(buffer, opts.keys.TraceId, entry.TraceId)
+	}
+	if opts.hasLocation() {
+		f.appendKeyValue(buffer, opts.keys.Location, entry.Location)
+	}
+	if opts.hasMessage() {
+		f.appendKeyValue(buffer, opts.keys.Message, entry.Message)
+	}
 	if fields := entry.Fields; len(fields) > 0 {
-		fixFieldsConflictAndHandleErrorFields(fields)
-
-		keys := make([]string, 0, len(fields))
-		for k := range fields {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
-		for _, k := range keys {
-			v := fields[k]
-			f.appendKeyValue(buffer, k, v)
-		}
+		fixFieldsConflictAndHandleErrorFields(fields, opts.stdKeys)
+
+		flattenFields(fields, opts.stdKeys, opts.flattenDepth, func(key string, value interface{}) {
+			f.appendKeyValue(buffer, key, value)
+		})
 	}
 	buffer.WriteByte('\n')
 	return buffer.Bytes(), nil
@@ -50,14 +59,5 @@ func (f textFormatter) appendKeyValue(b *bytes.Buffer, key string, value interfa
 }
 
 func (f textFormatter) appendValue(b *bytes.Buffer, value interface{}) {
-	var stringVal string
-	switch v := value.(type) {
-	case string:
-		stringVal = v
-	case json.RawMessage:
-		stringVal = string(v)
-	default:
-		stringVal = fmt.Sprint(value)
-	}
-	b.WriteString(stringVal)
+	b.WriteString(f.opts.textValue(value))
 }
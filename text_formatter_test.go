@@ -2,6 +2,7 @@ package log
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 )
@@ -45,3 +46,22 @@ func TestTextFormatter_Format(t *testing.T) {
 		return
 	}
 }
+
+func TestTextFormatter_FormatBadValue(t *testing.T) {
+	entry := &Entry{
+		Level:   InfoLevel,
+		Message: "msg",
+		Fields: map[string]interface{}{
+			"a": testPanicMarshaler{},
+		},
+	}
+	have, err := TextFormatter.Format(entry)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if want := "a=!ERROR: panic: marshal_panic_123456789\n"; !strings.HasSuffix(string(have), want) {
+		t.Errorf("have:%s, want suffix:%s", have, want)
+		return
+	}
+}
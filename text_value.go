@@ -0,0 +1,44 @@
+package log
+
+import (
+	"reflect"
+
+	internalfields "github.com/chanxuehong/log/internal/fields"
+)
+
+// BytesEncoding specifies how TextFormatter, LogfmtFormatter and so on write the []byte values.
+type BytesEncoding int
+
+const (
+	BytesEncodingBase64 BytesEncoding = iota // standard base64 encoding with padding, see RFC 4648
+	BytesEncodingHex                         // lower case hexadecimal encoding
+)
+
+// TextRenderer returns the string form of a field value, see RegisterTextRenderer.
+type TextRenderer func(value interface{}) string
+
+// RegisterTextRenderer registers the renderer for the field values which have the same type as sample,
+// it takes precedence over the built-in rendering of TextFormatter, LogfmtFormatter, ConsoleFormatter and CsvFormatter.
+// A nil renderer removes the registered one.
+//
+// It is normally called in the init function.
+func RegisterTextRenderer(sample interface{}, renderer TextRenderer) {
+	typ := reflect.TypeOf(sample)
+	if typ == nil {
+		return
+	}
+	internalfields.RegisterTextRenderer(typ, renderer)
+}
+
+var _defaultFormatterOptions = newFormatterOptions(nil)
+
+// textValue returns the string form of the field value with the default formatter options.
+func textValue(value interface{}) string {
+	return _defaultFormatterOptions.textValue(value)
+}
+
+// textValue returns the string form of the field value, []byte is encoded by WithBytesEncoding
+// and time.Time by WithTimeEncoding, see internalfields.TextEncoder.
+func (o *formatterOptions) textValue(value interface{}) string {
+	return o.textEncoder.Text(value)
+}
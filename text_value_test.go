@@ -0,0 +1,102 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+type textValueStringer struct{ name string }
+
+func (s textValueStringer) String() string { return "stringer:" + s.name }
+
+type textValueMarshaler struct{ fail bool }
+
+func (m textValueMarshaler) MarshalText() ([]byte, error) {
+	if m.fail {
+		return nil, errors.New("marshal failed")
+	}
+	return []byte("text"), nil
+}
+
+type textValueJSONMarshaler struct{}
+
+func (textValueJSONMarshaler) MarshalJSON() ([]byte, error) { return []byte(`{"a":1}`), nil }
+
+type textValueRendered struct{ id int }
+
+func TestFormatterOptions_TextValue(t *testing.T) {
+	var (
+		nilStringer *textValueStringer
+		nilError    error
+		n           = 123
+		pn          = &n
+	)
+	tests := []struct {
+		opts  []FormatterOption
+		value interface{}
+		want  string
+	}{
+		{nil, nil, "<nil>"},
+		{nil, "abc", "abc"},
+		{nil, json.RawMessage(`{"a":1}`), `{"a":1}`},
+		{nil, []byte("hello"), "aGVsbG8="},
+		{[]FormatterOption{WithBytesEncoding(BytesEncodingHex)}, []byte("hello"), "68656c6c6f"},
+		{nil, true, "true"},
+		{nil, -12, "-12"},
+		{nil, uint8(8), "8"},
+		{nil, 3.5, "3.5"},
+		{nil, float32(0.1), "0.1"},
+		{nil, time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC), "2018-05-20 16:20:30.666"},
+		{
+			[]FormatterOption{WithTimeLocation(time.UTC), WithTimeEncoding(TimeEncodingRFC3339)},
+			time.Date(2018, time.May, 20, 8, 20, 30, 666777888, time.UTC),
+			"2018-05-20T08:20:30Z",
+		},
+		{nil, 1500 * time.Millisecond, "1.5s"},
+		{nil, errors.New("error_123"), "error_123"},
+		{nil, nilError, "<nil>"},
+		{nil, textValueStringer{"a"}, "stringer:a"},
+		{nil, &textValueStringer{"b"}, "stringer:b"},
+		{nil, nilStringer, "<nil>"},
+		{nil, textValueMarshaler{}, "text"},
+		{nil, textValueMarshaler{fail: true}, "!ERROR: marshal failed"},
+		{nil, net.ParseIP("127.0.0.1"), "127.0.0.1"},
+		{nil, textValueJSONMarshaler{}, `{"a":1}`},
+		{nil, pn, "123"},
+		{nil, &pn, "123"},
+		{nil, struct{ A int }{1}, "{1}"},
+		{nil, []int{1, 2}, "[1 2]"},
+	}
+	for _, v := range tests {
+		if have := newFormatterOptions(v.opts).textValue(v.value); have != v.want {
+			t.Errorf("value:%#v, have:%s, want:%s", v.value, have, v.want)
+			return
+		}
+	}
+}
+
+func TestRegisterTextRenderer(t *testing.T) {
+	RegisterTextRenderer(textValueRendered{}, func(value interface{}) string {
+		return "rendered"
+	})
+	defer RegisterTextRenderer(textValueRendered{}, nil)
+
+	if have, want := textValue(textValueRendered{1}), "rendered"; have != want {
+		t.Errorf("have:%s, want:%s", have, want)
+		return
+	}
+	// the pointer is dereferenced
+	if have, want := textValue(&textValueRendered{1}), "rendered"; have != want {
+		t.Errorf("have:%s, want:%s", have, want)
+		return
+	}
+
+	RegisterTextRenderer(textValueRendered{}, nil)
+	if have, want := textValue(textValueRendered{1}), "{1}"; have != want {
+		t.Errorf("have:%s, want:%s", have, want)
+		return
+	}
+}